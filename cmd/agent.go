@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/nachoal/gwt/internal/agent"
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent [branch]",
+	Short: "Launch a coding agent in a worktree",
+	Long: "Launch a coding agent in the worktree for branch, or in the current worktree.\n\n" +
+		"Agents are defined in the agents: config section; claude and codex are built in.\n" +
+		"--agent default (or no --agent) uses settings.agent, falling back to claude.\n" +
+		"--issue builds the prompt from the agent's issue_prompt template. gwt exits with\n" +
+		"the agent's exit status.",
+	Example: "  gwt agent feature/foo --agent codex --prompt \"add tests for the parser\"\n" +
+		"  gwt agent --issue https://github.com/org/repo/issues/12\n" +
+		"  gwt agent --list",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		name, _ := cmd.Flags().GetString("agent")
+		prompt, _ := cmd.Flags().GetString("prompt")
+		issue, _ := cmd.Flags().GetString("issue")
+
+		if list {
+			return listAgents()
+		}
+
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		wt, err := statusTarget(worktrees, args)
+		if err != nil {
+			return err
+		}
+		if err := launchAgent(name, prompt, issue, wt.Path, wt.Branch); err != nil {
+			var exitErr *ExitCodeError
+			if errors.As(err, &exitErr) {
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+func listAgents() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	defaultName, _, _ := agent.Resolve(cfg.Agents, cfg.Settings.Agent, agent.Default)
+	for _, name := range agent.Names(cfg.Agents) {
+		_, a, err := agent.Resolve(cfg.Agents, cfg.Settings.Agent, name)
+		if err != nil {
+			fmt.Printf("%s\t(invalid: %v)\n", name, err)
+			continue
+		}
+		marker := ""
+		if name == defaultName {
+			marker = "\t(default)"
+		}
+		fmt.Printf("%s\t%s%s\n", name, a.Command, marker)
+	}
+	return nil
+}
+
+// launchAgent runs the named agent in the worktree at path on the terminal
+// and waits for it. A non-zero exit is returned as an *ExitCodeError.
+func launchAgent(name, prompt, issue, path, branch string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	name, a, err := agent.Resolve(cfg.Agents, cfg.Settings.Agent, name)
+	if err != nil {
+		return err
+	}
+	project, _ := worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+	if branch == "" {
+		branch = filepath.Base(path)
+	}
+
+	c, err := agent.Command(a, agent.Data{Prompt: prompt, Issue: issue, Branch: branch, Path: path, Project: project})
+	if err != nil {
+		return err
+	}
+	c.Env = append(os.Environ(), worktreeEnv(project, branch, path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// The shell integration captures stdout; the agent needs the terminal.
+	if !isTTY(os.Stdout) {
+		c.Stdout = os.Stderr
+	}
+
+	// Ctrl-C belongs to the agent; gwt must survive it to report the path.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	err = c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitCodeError{Code: exitStatus(exitErr)}
+	}
+	if err != nil {
+		return fmt.Errorf("agent '%s': %w", name, err)
+	}
+	return nil
+}
+
+// addAgentFlags adds the agent flags shared by gwt agent and gwt new.
+func addAgentFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("agent", "a", "", "Agent to launch (see 'gwt agent --list'; 'default' is settings.agent)")
+	cmd.Flags().StringP("prompt", "p", "", "Initial prompt for the agent")
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	addAgentFlags(agentCmd)
+	agentCmd.Flags().String("issue", "", "Issue URL; the prompt comes from the agent's issue_prompt template")
+	agentCmd.Flags().Bool("list", false, "List configured and built-in agents")
+}
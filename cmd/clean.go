@@ -7,6 +7,8 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/forge"
+	"github.com/nachoal/gwt/internal/journal"
 	"github.com/nachoal/gwt/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -19,7 +21,17 @@ var (
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Remove worktrees for merged branches",
+	Long: "Remove worktrees whose branch is merged into origin/main (or main), or into\n" +
+		"the base branch recorded when the worktree was created.\n\n" +
+		"When a forge is configured (settings.forge), worktrees whose pull request was\n" +
+		"merged are removed too, which also catches squash and rebase merges.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
 		cfg, err := config.LoadConfig()
 		if err != nil {
 			return err
@@ -52,6 +64,8 @@ var cleanCmd = &cobra.Command{
 		for _, line := range strings.Split(string(output), "\n") {
 			branch := strings.TrimSpace(line)
 			branch = strings.TrimPrefix(branch, "* ")
+			// Branches checked out in other worktrees are prefixed with "+ ".
+			branch = strings.TrimPrefix(branch, "+ ")
 			if branch != "" && branch != "main" && branch != "master" {
 				mergedBranches[branch] = true
 			}
@@ -63,13 +77,42 @@ var cleanCmd = &cobra.Command{
 			return err
 		}
 
+		commonGitDir, _ := worktree.CurrentCommonGitDir()
+		entry := journal.New("clean", args)
+		defer recordJournal(commonGitDir, entry)
+
+		provider := forgeProvider(cfg)
+		mainWT, _ := worktree.FindMainWorktree()
+
 		removedCount := 0
 		for _, wt := range worktrees {
+			reason := ""
 			if mergedBranches[wt.Branch] {
-				fmt.Printf("Removing merged worktree: %s\n", fileStyle.Render(wt.Branch))
-				if err := worktree.Remove(wt.Path, false); err != nil {
+				reason = "merged"
+			} else if base := worktree.RecordedBase(wt.Path); wt.Branch != "" && base != "" && base != wt.Branch && worktree.IsAncestor(wt.Branch, worktree.SyncTarget(base)) {
+				reason = "merged into " + base
+			} else if provider != nil && wt.Branch != "" && wt.Branch != "main" && wt.Branch != "master" && !worktree.SamePath(wt.Path, mainWT) {
+				// Squash and rebase merges are invisible to `git branch --merged`.
+				reason = mergedPullRequest(provider, wt.Branch)
+			}
+			if reason != "" {
+				if l := worktree.ActiveLease(wt.Path); l != nil {
+					fmt.Printf("Skipping leased worktree: %s %s\n", fileStyle.Render(wt.Branch), infoStyle.Render("("+reason+"; leased by "+describeLease(l)+")"))
+					continue
+				}
+				if wt.Locked {
+					locked := "locked"
+					if wt.LockReason != "" {
+						locked += ": " + wt.LockReason
+					}
+					fmt.Printf("Skipping locked worktree: %s %s\n", fileStyle.Render(wt.Branch), infoStyle.Render("("+reason+"; "+locked+")"))
+					continue
+				}
+				fmt.Printf("Removing merged worktree: %s %s\n", fileStyle.Render(wt.Branch), infoStyle.Render("("+reason+")"))
+				if err := worktree.Remove(cfg.Settings.Root, wt.Path, false); err != nil {
 					fmt.Printf("  %s Failed: %v\n", xMark, err)
 				} else {
+					entry.AddWorktree(journal.ActionRemoved, wt.Path, wt.Branch, wt.Head)
 					fmt.Printf("  %s Done\n", checkMark)
 					removedCount++
 				}
@@ -87,6 +130,15 @@ var cleanCmd = &cobra.Command{
 	},
 }
 
+// mergedPullRequest returns why branch counts as merged on the forge, such as
+// "PR #12 merged", or "" if its pull request is not merged or there is none.
+func mergedPullRequest(p forge.Provider, branch string) string {
+	if pr := lookupPullRequest(p, branch); pr != nil && pr.State == forge.StateMerged {
+		return fmt.Sprintf("PR #%d merged", pr.Number)
+	}
+	return ""
+}
+
 func init() {
 	rootCmd.AddCommand(cleanCmd)
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/nachoal/gwt/internal/forge"
+)
+
+func TestMergedPullRequest(t *testing.T) {
+	p := fileForge(t, []forge.PullRequest{
+		{Number: 1, State: "MERGED", Branch: "squashed"},
+		{Number: 2, State: "merged", Branch: "rebased"},
+		{Number: 3, State: "OPEN", Branch: "wip"},
+		{Number: 4, State: "closed", Branch: "abandoned"},
+		{Number: 5, State: "opened", Branch: "reopened"},
+		{Number: 6, State: "merged", Branch: "reopened"},
+		{Number: 7, State: "merged", Branch: "superseded"},
+		{Number: 8, State: "opened", Branch: "superseded"},
+	})
+
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"squashed", "PR #1 merged"},
+		{"rebased", "PR #2 merged"},
+		{"wip", ""},
+		{"abandoned", ""},
+		{"no-pr", ""},
+		{"", ""},
+		// The last entry for a branch wins.
+		{"reopened", "PR #6 merged"},
+		{"superseded", ""},
+	}
+	for _, tt := range tests {
+		if got := mergedPullRequest(p, tt.branch); got != tt.want {
+			t.Errorf("mergedPullRequest(%q) = %q, want %q", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestMergedPullRequestWithoutForge(t *testing.T) {
+	if got := mergedPullRequest(nil, "squashed"); got != "" {
+		t.Errorf("mergedPullRequest without a provider = %q, want \"\"", got)
+	}
+	// A missing file means no pull requests, not an error.
+	t.Setenv("GWT_FORGE", "")
+	p, err := forge.New("file:"+t.TempDir()+"/missing.json", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mergedPullRequest(p, "squashed"); got != "" {
+		t.Errorf("mergedPullRequest with a missing file = %q, want \"\"", got)
+	}
+}
@@ -0,0 +1,460 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/agent"
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/forge"
+	"github.com/nachoal/gwt/internal/journal"
+	"github.com/nachoal/gwt/internal/registry"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+// Classes of problems gwt doctor checks for; --fix takes these names.
+const (
+	doctorStale    = "stale"
+	doctorLinks    = "links"
+	doctorOrphans  = "orphans"
+	doctorBranches = "branches"
+	doctorConfig   = "config"
+	doctorShell    = "shell"
+	doctorBinaries = "binaries"
+)
+
+var doctorChecks = []string{doctorStale, doctorLinks, doctorOrphans, doctorBranches, doctorConfig, doctorShell, doctorBinaries}
+
+// doctorManual names the classes --fix cannot repair, with what to do instead.
+var doctorManual = map[string]string{
+	doctorOrphans:  "delete orphaned directories with 'gwt prune --root', which asks first",
+	doctorConfig:   "edit .worktree.yaml by hand",
+	doctorBinaries: "remove the extra binaries by hand",
+}
+
+var doctorTitles = map[string]string{
+	doctorStale:    "Stale worktree registrations",
+	doctorLinks:    "Broken worktree links",
+	doctorOrphans:  "Orphaned worktree directories",
+	doctorBranches: "Branches checked out nowhere",
+	doctorConfig:   "Configuration",
+	doctorShell:    "Shell integration",
+	doctorBinaries: "gwt binaries",
+}
+
+// doctorFixable returns the classes --fix can repair.
+func doctorFixable() []string {
+	var names []string
+	for _, name := range doctorChecks {
+		if doctorManual[name] == "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// doctorFinding is one problem found by gwt doctor.
+type doctorFinding struct {
+	Check   string `json:"check"`
+	Subject string `json:"subject"`
+	Problem string `json:"problem"`
+	// Fix is what --fix does, or how to fix the problem by hand.
+	Fix     string `json:"fix"`
+	Fixable bool   `json:"fixable"`
+	Fixed   bool   `json:"fixed"`
+	Error   string `json:"error,omitempty"`
+
+	fix func() error
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose and repair broken worktrees and installations",
+	Long: "Check for problems gwt and git do not notice on their own:\n" +
+		"  stale     worktrees registered with git or the root registry whose directory is gone\n" +
+		"  links     worktrees whose .git link is broken, e.g. after moving the main repository\n" +
+		"  orphans   worktree directories under the root that no repository knows about\n" +
+		"  branches  branches gwt created a worktree for that are now checked out nowhere\n" +
+		"  config    an invalid or misspelled .worktree.yaml\n" +
+		"  shell     outdated shell helper scripts in ~/.config/gwt\n" +
+		"  binaries  several gwt binaries on PATH\n\n" +
+		"--fix repairs every class it can (stale, links, branches, shell); --fix=stale,links\n" +
+		"limits it to some. Branches are only deleted if merged. Orphans are left to\n" +
+		"'gwt prune --root'. gwt doctor exits with status 1 while problems remain.",
+	Example: "  gwt doctor\n" +
+		"  gwt doctor --fix\n" +
+		"  gwt doctor --fix=stale,links --json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		fixClasses, _ := cmd.Flags().GetStringSlice("fix")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		fix := map[string]bool{}
+		for _, c := range fixClasses {
+			switch {
+			case c == "all":
+				for _, name := range doctorChecks {
+					fix[name] = true
+				}
+			case doctorManual[c] != "":
+				return fmt.Errorf("gwt doctor cannot fix %s; %s", c, doctorManual[c])
+			case doctorTitles[c] != "":
+				fix[c] = true
+			default:
+				return fmt.Errorf("unknown check %q for --fix (expected all or %s)", c, strings.Join(doctorFixable(), ", "))
+			}
+		}
+
+		var findings []*doctorFinding
+		cfg, cfgErr := config.LoadConfig()
+		findings = append(findings, checkConfig(cfg, cfgErr)...)
+		findings = append(findings, checkShellHelpers()...)
+		findings = append(findings, checkBinaries()...)
+
+		commonGitDir, repoErr := worktree.CurrentCommonGitDir()
+		if repoErr == nil {
+			if len(fix) > 0 {
+				release, err := acquireRepoLock(cmd, args)
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+			repoFindings, err := checkRepository(commonGitDir, args)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, repoFindings...)
+		} else if format == outputFormatPretty {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: not in a git repository; skipping repository checks"))
+		}
+		if cfgErr == nil {
+			findings = append(findings, checkRoot(cfg.Settings.Root, findings)...)
+		}
+
+		for _, f := range findings {
+			if !fix[f.Check] || !f.Fixable || f.fix == nil {
+				continue
+			}
+			if err := f.fix(); err != nil {
+				f.Error = err.Error()
+			} else {
+				f.Fixed = true
+			}
+		}
+
+		if err := printDoctor(findings, format, len(fix) > 0); err != nil {
+			return err
+		}
+		for _, f := range findings {
+			if !f.Fixed {
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+				return &ExitCodeError{Code: 1}
+			}
+		}
+		return nil
+	},
+}
+
+func checkConfig(cfg *config.Config, loadErr error) []*doctorFinding {
+	const file = ".worktree.yaml"
+	problem := func(p string) *doctorFinding {
+		return &doctorFinding{Check: doctorConfig, Subject: file, Problem: p, Fix: "edit " + file}
+	}
+	if loadErr != nil {
+		return []*doctorFinding{problem(loadErr.Error())}
+	}
+	var findings []*doctorFinding
+	problems, err := config.Lint(file)
+	if err != nil {
+		findings = append(findings, problem(err.Error()))
+	}
+	for _, p := range problems {
+		findings = append(findings, problem(p))
+	}
+	if _, err := worktree.RenderWorktreePath(cfg.Settings.PathTemplate, cfg.Settings.Root, "project", "feature/example"); err != nil {
+		findings = append(findings, problem("settings.path_template: "+err.Error()))
+	}
+	if _, _, err := agent.Resolve(cfg.Agents, cfg.Settings.Agent, agent.Default); err != nil {
+		findings = append(findings, problem("settings.agent: "+err.Error()))
+	}
+	if _, err := forge.New(cfg.Settings.Forge, "", ""); err != nil {
+		findings = append(findings, problem("settings.forge: "+err.Error()))
+	}
+	return findings
+}
+
+// checkShellHelpers flags helper scripts written by `gwt shell --install`
+// that differ from what this version would write.
+func checkShellHelpers() []*doctorFinding {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	var findings []*doctorFinding
+	for _, name := range []string{"shell.zsh", "shell.bash", "shell.sh"} {
+		path := filepath.Join(home, ".config", "gwt", name)
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) == shellFunction+"\n" {
+			continue
+		}
+		findings = append(findings, &doctorFinding{
+			Check:   doctorShell,
+			Subject: path,
+			Problem: "shell helper is outdated",
+			Fix:     "rewrite it for this gwt version",
+			Fixable: true,
+			fix: func() error {
+				return os.WriteFile(path, []byte(shellFunction+"\n"), 0o644)
+			},
+		})
+	}
+	return findings
+}
+
+func checkBinaries() []*doctorFinding {
+	onPath := gwtOnPath()
+	if len(onPath) < 2 {
+		return nil
+	}
+	return []*doctorFinding{{
+		Check:   doctorBinaries,
+		Subject: onPath[0],
+		Problem: fmt.Sprintf("%d gwt binaries on PATH (%s); the first one runs", len(onPath), strings.Join(onPath, ", ")),
+		Fix:     "remove the binaries you do not use",
+	}}
+}
+
+// checkRepository looks for stale registrations, broken links and stray
+// branches in the current repository.
+func checkRepository(commonGitDir string, args []string) ([]*doctorFinding, error) {
+	all, err := worktree.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	var findings []*doctorFinding
+	checkedOut := map[string]bool{}
+	for i, wt := range all {
+		if wt.Branch != "" {
+			checkedOut[wt.Branch] = true
+		}
+		switch {
+		case wt.Bare:
+		case wt.Prunable:
+			f := &doctorFinding{
+				Check:   doctorStale,
+				Subject: wt.Path,
+				Problem: "registered with git but " + wt.PrunableReason,
+				Fix:     "git worktree prune",
+				Fixable: !wt.Locked,
+				fix:     func() error { return worktree.Prune(commonGitDir) },
+			}
+			if wt.Locked {
+				f.Fix = "locked" + describeLockReason(wt) + "; 'gwt unlock " + wt.Name() + "', then 'gwt doctor --fix=stale'"
+			}
+			findings = append(findings, f)
+		case i == 0:
+			// The main worktree's .git is a directory.
+		default:
+			state, gitDir := worktree.CheckLink(wt.Path)
+			if state == worktree.LinkOK {
+				continue
+			}
+			path := wt.Path
+			findings = append(findings, &doctorFinding{
+				Check:   doctorLinks,
+				Subject: path,
+				Problem: describeLink(state, gitDir),
+				Fix:     "git worktree repair",
+				Fixable: true,
+				fix:     func() error { return worktree.Repair(commonGitDir, path) },
+			})
+		}
+	}
+
+	entries, err := journal.Read(commonGitDir)
+	if err != nil {
+		return nil, err
+	}
+	defaultBranch, _ := worktree.GetDefaultBranch()
+	seen := map[string]bool{}
+	var branches []string
+	for _, e := range entries {
+		for _, w := range e.Worktrees {
+			if w.Action == journal.ActionAdded && w.Branch != "" && !seen[w.Branch] {
+				seen[w.Branch] = true
+				branches = append(branches, w.Branch)
+			}
+		}
+	}
+	sort.Strings(branches)
+	for _, b := range branches {
+		if checkedOut[b] || b == defaultBranch || worktree.BranchSHA(commonGitDir, b) == "" {
+			continue
+		}
+		branch := b
+		findings = append(findings, &doctorFinding{
+			Check:   doctorBranches,
+			Subject: branch,
+			Problem: "gwt created a worktree for this branch; it is checked out nowhere now",
+			Fix:     "git branch -d (merged branches only)",
+			Fixable: true,
+			fix: func() error {
+				before := worktree.BranchSHA(commonGitDir, branch)
+				if err := worktree.DeleteBranchWithGitDir(commonGitDir, branch, false); err != nil {
+					return fmt.Errorf("not merged; delete it with 'git branch -D %s'", branch)
+				}
+				entry := journal.New("doctor", args)
+				entry.AddBranch(branch, before, worktree.BranchSHA(commonGitDir, branch))
+				recordJournal(commonGitDir, entry)
+				return nil
+			},
+		})
+	}
+	return findings, nil
+}
+
+// checkRoot looks for stale registry entries and for worktree directories
+// under root whose repository is gone or does not know them.
+func checkRoot(root string, reported []*doctorFinding) []*doctorFinding {
+	done := map[string]bool{}
+	for _, f := range reported {
+		done[f.Subject] = true
+	}
+	var findings []*doctorFinding
+	if stale, err := registry.Stale(root); err == nil && len(stale) > 0 {
+		fixRegistry := func() error {
+			return registry.Update(root, func(*registry.Registry) error { return nil })
+		}
+		for _, e := range stale {
+			if done[e.Path] {
+				continue
+			}
+			findings = append(findings, &doctorFinding{
+				Check:   doctorStale,
+				Subject: e.Path,
+				Problem: "recorded in " + registry.Path(root) + " but the directory is gone",
+				Fix:     "drop the entry and release its ports",
+				Fixable: true,
+				fix:     fixRegistry,
+			})
+		}
+	}
+	for _, dir := range worktree.WorktreeDirs(root) {
+		if done[dir] {
+			continue
+		}
+		state, gitDir := worktree.CheckLink(dir)
+		switch state {
+		case worktree.LinkOK:
+		case worktree.LinkBroken:
+			path := dir
+			findings = append(findings, &doctorFinding{
+				Check:   doctorLinks,
+				Subject: path,
+				Problem: describeLink(state, gitDir),
+				Fix:     "git worktree repair (in the worktree)",
+				Fixable: true,
+				fix:     func() error { return worktree.RepairFrom(path) },
+			})
+		default:
+			findings = append(findings, &doctorFinding{
+				Check:   doctorOrphans,
+				Subject: dir,
+				Problem: describeLink(state, gitDir),
+				Fix:     "if its repository moved, run 'git worktree repair " + dir + "' there; otherwise remove it with 'gwt prune'",
+			})
+		}
+	}
+	return findings
+}
+
+func describeLink(state, gitDir string) string {
+	switch state {
+	case worktree.LinkMissing:
+		return "its .git file points to " + gitDir + ", which does not exist (repository moved or deleted?)"
+	case worktree.LinkBroken:
+		return gitDir + " records a different location for this worktree (worktree moved or copied?)"
+	}
+	return "its .git file is unreadable"
+}
+
+func printDoctor(findings []*doctorFinding, format outputFormat, fixing bool) error {
+	switch format {
+	case outputFormatJSON:
+		if findings == nil {
+			findings = []*doctorFinding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	case outputFormatPlain:
+		for _, f := range findings {
+			fmt.Printf("%s\t%s\t%s\t%t\t%t\n", f.Check, f.Subject, f.Problem, f.Fixable, f.Fixed)
+		}
+		return nil
+	}
+
+	if len(findings) == 0 {
+		fmt.Println(successStyle.Render("✓") + " No problems found")
+		return nil
+	}
+	remaining, fixable := 0, 0
+	for _, check := range doctorChecks {
+		first := true
+		for _, f := range findings {
+			if f.Check != check {
+				continue
+			}
+			if first {
+				fmt.Println(titleStyle.Render(doctorTitles[check]))
+				first = false
+			}
+			switch {
+			case f.Fixed:
+				fmt.Printf(" %s %s %s\n", checkMark, fileStyle.Render(f.Subject), infoStyle.Render("fixed: "+f.Fix))
+				continue
+			case f.Error != "":
+				fmt.Printf(" %s %s: %s\n", xMark, fileStyle.Render(f.Subject), f.Problem)
+				fmt.Printf("   %s\n", infoStyle.Render("fix failed: "+f.Error))
+			default:
+				fmt.Printf(" %s %s: %s\n", xMark, fileStyle.Render(f.Subject), f.Problem)
+				fmt.Printf("   %s\n", infoStyle.Render("fix: "+f.Fix))
+			}
+			remaining++
+			if f.Fixable && f.Error == "" {
+				fixable++
+			}
+		}
+		if !first {
+			fmt.Println()
+		}
+	}
+	switch {
+	case remaining == 0:
+		fmt.Println(successStyle.Render("✓") + " All problems fixed")
+	case fixable > 0 && !fixing:
+		fmt.Printf("%d problem(s); 'gwt doctor --fix' can fix %d of them\n", remaining, fixable)
+	default:
+		fmt.Printf("%d problem(s) left\n", remaining)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringSlice("fix", nil, "Fix problems of these classes (all, or "+strings.Join(doctorFixable(), ",")+")")
+	doctorCmd.Flags().Lookup("fix").NoOptDefVal = "all"
+	doctorCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	doctorCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/forge"
+	"github.com/nachoal/gwt/internal/journal"
 	"github.com/nachoal/gwt/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -15,15 +19,57 @@ var doneCmd = &cobra.Command{
 	Short: "Update base branch and remove a completed worktree",
 	Long: "Finalize work on a branch by updating the base branch and removing the branch's worktree.\n\n" +
 		"If branch is omitted, gwt infers it from the current worktree.\n" +
-		"If base is omitted, gwt uses the repository default branch.",
+		"If base is omitted, gwt uses the base recorded when the worktree was created,\n" +
+		"falling back to the repository default branch.\n\n" +
+		"By default gwt assumes the branch was merged elsewhere and refuses to finish it\n" +
+		"unless it is merged into the updated base (or its pull request was merged).\n" +
+		"To integrate it locally instead, in the base branch's worktree:\n" +
+		"  • --merge    merge the branch with a merge commit\n" +
+		"  • --squash   squash the branch into a single commit\n" +
+		"  • --rebase   rebase the branch onto the base, then fast-forward the base\n" +
+		"Conflicts abort the integration and nothing is removed. --push pushes the base afterwards.",
+	Example: "  gwt done\n" +
+		"  gwt done feature/foo develop\n" +
+		"  gwt done feature/foo --squash --push",
 	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		push, _ := cmd.Flags().GetBool("push")
+		strategy, err := doneStrategyFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		if push && strategy == "" {
+			return fmt.Errorf("--push requires --merge, --squash or --rebase")
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
 		branchName, err := resolveDoneBranch(args)
 		if err != nil {
 			return err
 		}
+		// Refuse a leased or locked worktree before integrating or pushing anything.
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		wt, err := worktree.Find(worktrees, branchName)
+		var notFound *worktree.NotFoundError
+		switch {
+		case err == nil:
+			if err := checkUnlocked(wt); err != nil {
+				return err
+			}
+		case !errors.As(err, &notFound):
+			return err
+		}
 
-		baseBranch, err := resolveDoneBase(args)
+		baseBranch, err := resolveDoneBase(args, branchName)
 		if err != nil {
 			return err
 		}
@@ -31,8 +77,23 @@ var doneCmd = &cobra.Command{
 			return fmt.Errorf("refusing to remove base branch '%s'", baseBranch)
 		}
 
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		pr := lookupPullRequest(forgeProvider(cfg), branchName)
+		if err := checkOpenPullRequest(pr, branchName, force); err != nil {
+			return err
+		}
+
+		commonGitDir, _ := worktree.CurrentCommonGitDir()
+		entry := journal.New("done", args)
+		defer recordJournal(commonGitDir, entry)
+
+		baseBefore := worktree.BranchSHA(commonGitDir, baseBranch)
 		basePath, usedBaseWorktree, err := updateBaseBranch(baseBranch)
 		if err != nil {
+			entry.AddBranch(baseBranch, baseBefore, worktree.BranchSHA(commonGitDir, baseBranch))
 			return err
 		}
 
@@ -42,7 +103,36 @@ var doneCmd = &cobra.Command{
 			fmt.Fprintln(os.Stderr, infoStyle.Render("✓ Updated local base branch ref ")+fileStyle.Render(baseBranch))
 		}
 
-		if err := removeWorktreeByBranch(branchName, false); err != nil {
+		if strategy != "" {
+			err := integrateBranch(strategy, branchName, baseBranch, basePath, usedBaseWorktree, commonGitDir, entry)
+			entry.AddBranch(baseBranch, baseBefore, worktree.BranchSHA(commonGitDir, baseBranch))
+			if err != nil {
+				return err
+			}
+			if push {
+				remote := worktree.DefaultRemote()
+				if remote == "" {
+					return fmt.Errorf("--push requires a configured remote")
+				}
+				if err := runGitInDir(basePath, "push", remote, baseBranch); err != nil {
+					return fmt.Errorf("%w; '%s' was not removed", err, branchName)
+				}
+				fmt.Fprintln(os.Stderr, infoStyle.Render("✓ Pushed ")+fileStyle.Render(baseBranch)+infoStyle.Render(" to "+remote))
+			}
+		} else {
+			entry.AddBranch(baseBranch, baseBefore, worktree.BranchSHA(commonGitDir, baseBranch))
+		}
+
+		// A squash, or a pull request merged by squashing or rebasing on the
+		// forge, leaves the branch unreachable from the base.
+		integrated := strategy == doneSquash || (pr != nil && pr.State == forge.StateMerged)
+		if !force && !integrated && !worktree.IsAncestor(branchName, baseBranch) {
+			return fmt.Errorf("branch '%s' is not merged into '%s'; use --merge, --squash or --rebase to integrate it, or --force to discard it", branchName, baseBranch)
+		}
+
+		// The branch is known to be integrated (or --force was given), so delete
+		// it even if `git branch -d` would not consider it merged into HEAD.
+		if err := removeWorktreeByBranch(branchName, false, true, entry); err != nil {
 			return err
 		}
 		fmt.Fprintln(os.Stderr, successStyle.Render("✓")+" Done: removed "+fileStyle.Render(branchName))
@@ -55,6 +145,117 @@ var doneCmd = &cobra.Command{
 	},
 }
 
+// checkOpenPullRequest refuses to finish branch while its pull request is
+// still open, unless force is set.
+func checkOpenPullRequest(pr *forge.PullRequest, branch string, force bool) error {
+	if !force && pr != nil && pr.State == forge.StateOpen {
+		return fmt.Errorf("pull request #%d for '%s' is still open (%s); merge or close it first, or use --force", pr.Number, branch, pr.URL)
+	}
+	return nil
+}
+
+// Integration strategies for `gwt done`.
+const (
+	doneMerge  = "merge"
+	doneSquash = "squash"
+	doneRebase = "rebase"
+)
+
+func doneStrategyFromFlags(cmd *cobra.Command) (string, error) {
+	strategy := ""
+	for _, s := range []string{doneMerge, doneSquash, doneRebase} {
+		if on, _ := cmd.Flags().GetBool(s); on {
+			if strategy != "" {
+				return "", fmt.Errorf("--%s and --%s are mutually exclusive", strategy, s)
+			}
+			strategy = s
+		}
+	}
+	return strategy, nil
+}
+
+// integrateBranch merges, squashes or rebases branch into base, which must be
+// checked out at basePath with a clean tree. On conflicts the operation is
+// aborted, leaving both branches as they were.
+func integrateBranch(strategy, branch, base, basePath string, usedBaseWorktree bool, commonGitDir string, entry *journal.Entry) error {
+	if !usedBaseWorktree {
+		return fmt.Errorf("--%s needs '%s' checked out in a worktree; check it out first", strategy, base)
+	}
+	// The base worktree is clean from here on, so whatever a failed merge
+	// leaves behind is ours to abort.
+	if err := checkBaseClean(basePath, base); err != nil {
+		return err
+	}
+
+	switch strategy {
+	case doneMerge:
+		if err := runGitInDir(basePath, "merge", "--no-ff", "--no-edit", branch); err != nil {
+			if mergeInProgress(basePath) {
+				_ = runGitInDir(basePath, "merge", "--abort")
+			}
+			return fmt.Errorf("merging '%s' into '%s' failed; merge aborted, nothing was removed: %w", branch, base, err)
+		}
+	case doneSquash:
+		if err := runGitInDir(basePath, "merge", "--squash", branch); err != nil {
+			if hasChanges(basePath) {
+				_ = runGitInDir(basePath, "reset", "--merge")
+			}
+			return fmt.Errorf("squashing '%s' into '%s' failed; squash aborted, nothing was removed: %w", branch, base, err)
+		}
+		// Nothing staged means the branch's changes are already in base.
+		if exec.Command("git", "-C", basePath, "diff", "--cached", "--quiet").Run() != nil {
+			if err := runGitInDir(basePath, "commit", "--no-edit"); err != nil {
+				_ = runGitInDir(basePath, "reset", "--merge")
+				return fmt.Errorf("committing squash of '%s' failed; nothing was removed: %w", branch, err)
+			}
+		}
+	case doneRebase:
+		branchPath, err := worktreePathForBranch(branch)
+		if err != nil {
+			return err
+		}
+		if branchPath == "" {
+			return fmt.Errorf("worktree for branch '%s' not found", branch)
+		}
+		branchBefore := worktree.BranchSHA(commonGitDir, branch)
+		err = runGitInDir(branchPath, "rebase", base)
+		if err != nil {
+			_ = runGitInDir(branchPath, "rebase", "--abort")
+			return fmt.Errorf("rebasing '%s' onto '%s' failed; rebase aborted, nothing was removed: %w", branch, base, err)
+		}
+		entry.AddBranch(branch, branchBefore, worktree.BranchSHA(commonGitDir, branch))
+		if err := runGitInDir(basePath, "merge", "--ff-only", branch); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(os.Stderr, infoStyle.Render("✓ Integrated ")+fileStyle.Render(branch)+infoStyle.Render(" into "+base+" ("+strategy+")"))
+	return nil
+}
+
+// checkBaseClean refuses to integrate into the base worktree at basePath while
+// it has changes or an unfinished merge, which a failed merge would discard or
+// a squash would commit.
+func checkBaseClean(basePath, base string) error {
+	if mergeInProgress(basePath) {
+		return fmt.Errorf("'%s' at %s has a merge in progress; finish or abort it first", base, basePath)
+	}
+	if hasChanges(basePath) {
+		return fmt.Errorf("'%s' at %s has uncommitted changes; commit or stash them first", base, basePath)
+	}
+	return nil
+}
+
+// hasChanges reports staged, unstaged or untracked changes in the worktree at
+// path; an unreadable status counts as changed.
+func hasChanges(path string) bool {
+	out, err := exec.Command("git", "-C", path, "status", "--porcelain").Output()
+	return err != nil || strings.TrimSpace(string(out)) != ""
+}
+
+func mergeInProgress(path string) bool {
+	return exec.Command("git", "-C", path, "rev-parse", "-q", "--verify", "MERGE_HEAD").Run() == nil
+}
+
 func resolveDoneBranch(args []string) (string, error) {
 	if len(args) >= 1 && strings.TrimSpace(args[0]) != "" {
 		return args[0], nil
@@ -66,10 +267,17 @@ func resolveDoneBranch(args []string) (string, error) {
 	return branch, nil
 }
 
-func resolveDoneBase(args []string) (string, error) {
+// resolveDoneBase returns the explicit base argument, else the base recorded
+// when branch's worktree was created, else the repository default branch.
+func resolveDoneBase(args []string, branch string) (string, error) {
 	if len(args) >= 2 && strings.TrimSpace(args[1]) != "" {
 		return args[1], nil
 	}
+	if path, _ := worktreePathForBranch(branch); path != "" {
+		if base := worktree.RecordedBase(path); base != "" {
+			return base, nil
+		}
+	}
 	base, err := worktree.GetDefaultBranch()
 	if err != nil {
 		return "", err
@@ -118,12 +326,12 @@ func worktreePathForBranch(branch string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	for _, wt := range worktrees {
-		if wt.Branch == branch {
-			return wt.Path, nil
-		}
+	wt, err := worktree.Find(worktrees, branch)
+	var notFound *worktree.NotFoundError
+	if errors.As(err, &notFound) {
+		return "", nil
 	}
-	return "", nil
+	return wt.Path, err
 }
 
 func runGitInDir(dir string, args ...string) error {
@@ -139,6 +347,11 @@ func runGitInDir(dir string, args ...string) error {
 
 func init() {
 	rootCmd.AddCommand(doneCmd)
+	doneCmd.Flags().Bool("merge", false, "Merge the branch into the base locally (merge commit)")
+	doneCmd.Flags().Bool("squash", false, "Squash the branch into a single commit on the base locally")
+	doneCmd.Flags().Bool("rebase", false, "Rebase the branch onto the base, then fast-forward the base")
+	doneCmd.Flags().Bool("push", false, "Push the base branch after integrating the branch")
+	doneCmd.Flags().Bool("force", false, "Finish the branch even if its pull request is open or it is not merged into the base")
 	doneCmd.Flags().Bool("print-path", false, "Print the selected base worktree path on success")
 	_ = doneCmd.Flags().MarkHidden("print-path")
 }
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nachoal/gwt/internal/forge"
+	"github.com/nachoal/gwt/internal/journal"
+)
+
+func TestCheckOpenPullRequest(t *testing.T) {
+	p := fileForge(t, []forge.PullRequest{
+		{Number: 10, State: "OPEN", Branch: "feature/open", URL: "https://example.com/pull/10"},
+		{Number: 11, State: "opened", Branch: "feature/mr", URL: "https://example.com/merge_requests/11"},
+		{Number: 12, State: "MERGED", Branch: "feature/merged"},
+		{Number: 13, State: "closed", Branch: "feature/closed"},
+	})
+
+	tests := []struct {
+		branch  string
+		force   bool
+		wantErr string
+	}{
+		{branch: "feature/open", wantErr: "pull request #10 for 'feature/open' is still open (https://example.com/pull/10)"},
+		{branch: "feature/mr", wantErr: "pull request #11 for 'feature/mr' is still open"},
+		{branch: "feature/open", force: true},
+		{branch: "feature/merged"},
+		{branch: "feature/closed"},
+		{branch: "feature/none"},
+	}
+	for _, tt := range tests {
+		err := checkOpenPullRequest(lookupPullRequest(p, tt.branch), tt.branch, tt.force)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s (force=%t): unexpected error %v", tt.branch, tt.force, err)
+		case tt.wantErr != "" && err == nil:
+			t.Errorf("%s (force=%t): expected an error", tt.branch, tt.force)
+		case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+			t.Errorf("%s (force=%t): error %q does not contain %q", tt.branch, tt.force, err, tt.wantErr)
+		}
+	}
+}
+
+// testRepo creates a repository on branch main with one commit of file f and
+// a branch feat that changes f.
+func testRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME": "gwt", "GIT_AUTHOR_EMAIL": "gwt@example.com",
+		"GIT_COMMITTER_NAME": "gwt", "GIT_COMMITTER_EMAIL": "gwt@example.com",
+		"GIT_CONFIG_GLOBAL": os.DevNull, "GIT_CONFIG_NOSYSTEM": "1",
+	} {
+		t.Setenv(k, v)
+	}
+	git(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, dir, "f", "base\n")
+	git(t, dir, "add", "f")
+	git(t, dir, "commit", "-q", "-m", "base")
+	git(t, dir, "checkout", "-q", "-b", "feat")
+	writeFile(t, dir, "f", "feat\n")
+	git(t, dir, "commit", "-q", "-am", "feat")
+	git(t, dir, "checkout", "-q", "main")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntegrateBranchRefusesDirtyBase(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		stage bool
+	}{
+		{name: "staged edit of a merged file", file: "f", stage: true},
+		{name: "staged unrelated file", file: "g", stage: true},
+		{name: "untracked file", file: "g"},
+	}
+	for _, strategy := range []string{doneMerge, doneSquash, doneRebase} {
+		for _, tt := range tests {
+			repo := testRepo(t)
+			writeFile(t, repo, tt.file, "mine\n")
+			if tt.stage {
+				git(t, repo, "add", tt.file)
+			}
+			before := git(t, repo, "rev-parse", "main")
+			status := git(t, repo, "status", "--porcelain")
+
+			err := integrateBranch(strategy, "feat", "main", repo, true, filepath.Join(repo, ".git"), journal.New("done", nil))
+			if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+				t.Errorf("%s, %s: expected an uncommitted changes error, got %v", strategy, tt.name, err)
+			}
+			if got := git(t, repo, "rev-parse", "main"); got != before {
+				t.Errorf("%s, %s: main moved from %s to %s", strategy, tt.name, before, got)
+			}
+			if got := git(t, repo, "status", "--porcelain"); got != status {
+				t.Errorf("%s, %s: status changed from %q to %q", strategy, tt.name, status, got)
+			}
+		}
+	}
+}
+
+func TestIntegrateBranchSquash(t *testing.T) {
+	repo := testRepo(t)
+	if err := integrateBranch(doneSquash, "feat", "main", repo, true, filepath.Join(repo, ".git"), journal.New("done", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if got := git(t, repo, "show", "--name-only", "--format=", "main"); strings.TrimSpace(got) != "f" {
+		t.Errorf("squash commit touches %q, want only f", got)
+	}
+	if got := git(t, repo, "status", "--porcelain"); got != "" {
+		t.Errorf("base not clean after squash: %q", got)
+	}
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+// execTarget is a worktree selected by `gwt exec`.
+type execTarget struct {
+	Project string
+	Branch  string
+	Path    string
+}
+
+// label names the target in prefixed and summary output.
+func (t execTarget) label() string {
+	name := t.Branch
+	if name == "" {
+		name = filepath.Base(t.Path)
+	}
+	if t.Project != "" {
+		return t.Project + "/" + name
+	}
+	return name
+}
+
+type execResult struct {
+	Project    string `json:"project,omitempty"`
+	Branch     string `json:"branch"`
+	Path       string `json:"path"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMs int64  `json:"duration_ms"`
+	Output     string `json:"output,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Skipped is set for worktrees not run because of --fail-fast.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+var execCmd = &cobra.Command{
+	Use:     "exec [flags] -- <command> [args...]",
+	Aliases: []string{"foreach"},
+	Short:   "Run a command in every worktree",
+	Long: "Run a command in each selected worktree, a few at a time.\n\n" +
+		"By default every worktree of the current repository is selected; --root selects\n" +
+		"every gwt worktree under settings.root instead. --branch (a glob such as\n" +
+		"'feature/*') and --tag narrow the selection.\n\n" +
+		"Output lines are prefixed with the worktree's branch, or grouped per worktree with\n" +
+		"--group. The command is run directly, not through a shell; use `sh -c '...'` for\n" +
+		"pipelines. gwt exits non-zero if the command failed in any worktree.",
+	Example: "  gwt exec -- git status --short\n" +
+		"  gwt exec --branch 'feature/*' -j 2 -- go build ./...\n" +
+		"  gwt exec --tag urgent --fail-fast --json -- npm test",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		rootMode, _ := cmd.Flags().GetBool("root")
+		pattern, _ := cmd.Flags().GetString("branch")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		group, _ := cmd.Flags().GetBool("group")
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		if all && rootMode {
+			return fmt.Errorf("--all and --root are mutually exclusive")
+		}
+		if pattern != "" {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid --branch pattern %q: %w", pattern, err)
+			}
+		}
+		if jobs < 1 {
+			jobs = 1
+		}
+
+		targets, err := execTargets(rootMode, pattern, tags)
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("no worktrees match")
+		}
+
+		results := runExec(targets, args, jobs, failFast, format == outputFormatJSON || group, format)
+		failed := 0
+		for _, r := range results {
+			if r.ExitCode != 0 && !r.Skipped {
+				failed++
+			}
+		}
+
+		switch format {
+		case outputFormatJSON:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return err
+			}
+		case outputFormatPlain:
+			for _, r := range results {
+				fmt.Printf("result\t%s\t%d\t%d\t%t\n", execTarget{r.Project, r.Branch, r.Path}.label(), r.ExitCode, r.DurationMs, r.Skipped)
+			}
+		default:
+			fmt.Fprintln(os.Stderr)
+			for _, r := range results {
+				label := execTarget{r.Project, r.Branch, r.Path}.label()
+				switch {
+				case r.Skipped:
+					fmt.Fprintf(os.Stderr, " %s %s %s\n", infoStyle.Render("-"), label, infoStyle.Render("skipped"))
+				case r.ExitCode == 0:
+					fmt.Fprintf(os.Stderr, " %s %s %s\n", checkMark, label, infoStyle.Render(formatDurationMs(r.DurationMs)))
+				default:
+					detail := fmt.Sprintf("exit %d", r.ExitCode)
+					if r.Error != "" {
+						detail = r.Error
+					}
+					fmt.Fprintf(os.Stderr, " %s %s %s\n", xMark, label, infoStyle.Render(detail+", "+formatDurationMs(r.DurationMs)))
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("command failed in %d of %d worktree(s)", failed, len(results))
+		}
+		return nil
+	},
+}
+
+// execTargets selects the worktrees for `gwt exec`.
+func execTargets(rootMode bool, pattern string, tags []string) ([]execTarget, error) {
+	var all []execTarget
+	if rootMode {
+		items, _, err := worktree.ListFromRoot("")
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			if it.Orphaned {
+				fmt.Fprintln(os.Stderr, infoStyle.Render("Note: skipping orphaned worktree "+it.Path+" (see 'gwt prune')"))
+				continue
+			}
+			all = append(all, execTarget{Project: it.Project, Branch: it.Branch, Path: it.Path})
+		}
+	} else {
+		worktrees, err := worktree.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, wt := range worktrees {
+			if wt.Prunable {
+				fmt.Fprintln(os.Stderr, infoStyle.Render("Note: skipping stale worktree "+wt.Path+" ("+wt.PrunableReason+")"))
+				continue
+			}
+			all = append(all, execTarget{Branch: wt.Branch, Path: wt.Path})
+		}
+	}
+
+	var targets []execTarget
+	for _, t := range all {
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, t.Branch); !ok {
+				continue
+			}
+		}
+		if len(tags) > 0 {
+			meta, _ := worktree.ReadMetadata(t.Path)
+			if !meta.HasTags(tags) {
+				continue
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// runExec runs argv in each target with at most jobs running at once. Output
+// is captured into the results when capture is set, and otherwise streamed to
+// stdout/stderr with each line prefixed by the worktree.
+func runExec(targets []execTarget, argv []string, jobs int, failFast, capture bool, format outputFormat) []execResult {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make([]execResult, len(targets))
+	var outMu sync.Mutex
+	// Workers take targets in order, so --fail-fast with -j 1 stops at the
+	// first failing worktree in list order.
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = execOne(ctx, cancel, targets[i], argv, failFast, capture, format, &outMu)
+			}
+		}()
+	}
+	for i := range targets {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+// execOne runs argv in t; see runExec.
+func execOne(ctx context.Context, cancel context.CancelFunc, t execTarget, argv []string, failFast, capture bool, format outputFormat, outMu *sync.Mutex) execResult {
+	res := execResult{Project: t.Project, Branch: t.Branch, Path: t.Path}
+	if ctx.Err() != nil {
+		res.Skipped, res.ExitCode = true, -1
+		return res
+	}
+
+	c := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	c.Dir = t.Path
+	c.Env = append(os.Environ(), worktreeEnv(t.Project, t.Branch, t.Path)...)
+
+	var buf bytes.Buffer
+	var stdout, stderr *prefixWriter
+	if capture {
+		c.Stdout, c.Stderr = &buf, &buf
+	} else {
+		prefix := "[" + t.label() + "] "
+		if format == outputFormatPretty {
+			prefix = fileStyle.Render("["+t.label()+"]") + " "
+		}
+		stdout = &prefixWriter{mu: outMu, prefix: prefix, out: os.Stdout}
+		stderr = &prefixWriter{mu: outMu, prefix: prefix, out: os.Stderr}
+		c.Stdout, c.Stderr = stdout, stderr
+	}
+
+	start := time.Now()
+	err := c.Run()
+	res.DurationMs = time.Since(start).Milliseconds()
+	if stdout != nil {
+		stdout.Flush()
+		stderr.Flush()
+	}
+	res.Output = buf.String()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+		if ctx.Err() != nil && res.ExitCode == -1 {
+			res.Error = "cancelled by --fail-fast"
+		}
+	default:
+		res.ExitCode = 127
+		res.Error = err.Error()
+	}
+	if res.ExitCode != 0 && failFast {
+		cancel()
+	}
+
+	// With --group (but not --json) print each worktree's output as it finishes.
+	if capture && format != outputFormatJSON {
+		outMu.Lock()
+		fmt.Fprintln(os.Stdout, fileStyle.Render("== "+t.label()+" =="))
+		fmt.Fprint(os.Stdout, res.Output)
+		outMu.Unlock()
+	}
+	return res
+}
+
+// worktreeEnv returns the GWT_* variables describing a worktree for commands
+// gwt runs inside it.
+func worktreeEnv(project, branch, path string) []string {
+	env := []string{
+		"GWT_WORKTREE=" + path,
+		"GWT_BRANCH=" + branch,
+	}
+	if project != "" {
+		env = append(env, "GWT_PROJECT="+project)
+	}
+	if base := worktree.RecordedBase(path); base != "" {
+		env = append(env, "GWT_BASE="+base)
+	}
+	return env
+}
+
+// prefixWriter writes complete lines to out, each prefixed, holding partial
+// lines back until they are completed or flushed.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	prefix string
+	out    io.Writer
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.mu.Lock()
+		fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf[:i])
+		w.mu.Unlock()
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes a trailing partial line, if any.
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	w.mu.Lock()
+	fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+	w.mu.Unlock()
+	w.buf = nil
+}
+
+func formatDurationMs(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(10 * time.Millisecond).String()
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	// Everything after the command name belongs to the command: gwt exec git log --oneline
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().Bool("all", false, "Run in every worktree of the current repository (the default)")
+	execCmd.Flags().Bool("root", false, "Run in every gwt worktree under settings.root")
+	execCmd.Flags().String("branch", "", "Only worktrees whose branch matches this glob (e.g. 'feature/*')")
+	execCmd.Flags().StringSlice("tag", nil, "Only worktrees with this tag (repeatable; all must match)")
+	execCmd.Flags().IntP("jobs", "j", 4, "Number of worktrees to run in concurrently")
+	execCmd.Flags().Bool("group", false, "Print each worktree's output as one block when it finishes instead of prefixing lines")
+	execCmd.Flags().Bool("fail-fast", false, "Stop starting new worktrees and cancel running ones after the first failure")
+	execCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	execCmd.Flags().Bool("json", false, "Machine-readable JSON results with captured output")
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/forge"
+	"github.com/nachoal/gwt/internal/worktree"
+)
+
+// forgeProvider returns the forge provider selected by settings.forge, or nil
+// when forge integration is off. Forge data only enriches other commands, so
+// problems are reported as warnings rather than errors.
+func forgeProvider(cfg *config.Config) forge.Provider {
+	mainWT, _ := worktree.FindMainWorktree()
+	p, err := forge.New(cfg.Settings.Forge, worktree.RemoteURL(), mainWT)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: "+err.Error()))
+		return nil
+	}
+	return p
+}
+
+// lookupPullRequest returns the pull request for branch, or nil if there is
+// none, the provider is nil or the lookup failed (which is reported).
+func lookupPullRequest(p forge.Provider, branch string) *forge.PullRequest {
+	if p == nil || branch == "" {
+		return nil
+	}
+	pr, err := p.PullRequest(branch)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render(fmt.Sprintf("Note: could not look up %s pull request for %s: %v", p.Name(), branch, err)))
+		return nil
+	}
+	return pr
+}
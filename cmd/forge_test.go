@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nachoal/gwt/internal/forge"
+)
+
+// fileForge returns the "file:" forge provider serving prs, as selected by
+// settings.forge.
+func fileForge(t *testing.T, prs []forge.PullRequest) forge.Provider {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prs.json")
+	data, err := json.Marshal(prs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GWT_FORGE", "")
+	p, err := forge.New("file:"+path, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/journal"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the journal of gwt operations for this repository",
+	Long: "Show the operation journal kept in the repository's common git dir.\n\n" +
+		"Every mutating command (new, remove, done, clean, undo) appends a record with\n" +
+		"the branches and worktrees it changed. Use 'gwt undo' to reverse the latest one.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+
+		commonGitDir, err := worktree.CurrentCommonGitDir()
+		if err != nil {
+			return fmt.Errorf("not in a git repository: %w", err)
+		}
+		entries, err := journal.Read(commonGitDir)
+		if err != nil {
+			return err
+		}
+		undone := journal.Undone(entries)
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+
+		if format == outputFormatJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+
+		if len(entries) == 0 {
+			if format == outputFormatPretty {
+				fmt.Println(infoStyle.Render("No recorded operations"))
+			}
+			if format == outputFormatPlain {
+				fmt.Println("count=0")
+			}
+			return nil
+		}
+
+		if format == outputFormatPretty {
+			fmt.Println(titleStyle.Render("History"))
+			fmt.Println(infoStyle.Render(fmt.Sprintf("%-13s  %-16s  %-6s  %s", "ID", "Time", "Op", "Changes")))
+		}
+		if format == outputFormatPlain {
+			fmt.Println("id\ttime\top\tundone\tchanges")
+		}
+
+		// Newest first
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+			summary := summarizeEntry(e)
+			if format == outputFormatPretty {
+				if undone[e.ID] {
+					summary += infoStyle.Render(" (undone)")
+				}
+				fmt.Printf("%-13s  %-16s  %-6s  %s\n", e.ID, e.Time.Local().Format("2006-01-02 15:04"), e.Op, summary)
+			}
+			if format == outputFormatPlain {
+				fmt.Printf("%s\t%s\t%s\t%t\t%s\n", e.ID, e.Time.Format("2006-01-02T15:04:05Z"), e.Op, undone[e.ID], summary)
+			}
+		}
+		return nil
+	},
+}
+
+// summarizeEntry renders a short one-line description of an entry's changes.
+func summarizeEntry(e journal.Entry) string {
+	var parts []string
+	if e.Undoes != "" {
+		parts = append(parts, "reverts "+e.Undoes)
+	}
+	for _, w := range e.Worktrees {
+		sign := "+"
+		if w.Action == journal.ActionRemoved {
+			sign = "-"
+		}
+		name := w.Branch
+		if name == "" {
+			name = w.Path
+		}
+		parts = append(parts, sign+"worktree "+name)
+	}
+	for _, b := range e.Branches {
+		switch {
+		case b.Before == "":
+			parts = append(parts, "+branch "+b.Name+"@"+shortSHA(b.After))
+		case b.After == "":
+			parts = append(parts, "-branch "+b.Name+"@"+shortSHA(b.Before))
+		default:
+			parts = append(parts, "branch "+b.Name+" "+shortSHA(b.Before)+"→"+shortSHA(b.After))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// recordJournal appends entry to the operation journal. Failures only warn so
+// that a journal problem never masks the outcome of the operation itself.
+func recordJournal(commonGitDir string, entry *journal.Entry) {
+	if commonGitDir == "" {
+		return
+	}
+	if err := journal.Append(commonGitDir, entry); err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not write operation journal: "+err.Error()))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().IntP("limit", "n", 20, "Show at most this many recent operations (0 for all)")
+	historyCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	historyCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
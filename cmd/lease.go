@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nachoal/gwt/internal/lease"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+type leaseInfo struct {
+	lease.Lease
+	Expired bool `json:"expired"`
+}
+
+var leaseCmd = &cobra.Command{
+	Use:   "lease",
+	Short: "Claim worktrees for automated agents",
+	Long: "A lease gives one owner (an agent, a CI job) exclusive use of a worktree for a\n" +
+		"limited time. The worktree is locked with 'git worktree lock' while leased, and\n" +
+		"'gwt remove', 'gwt clean' and 'gwt done' refuse to touch it until the lease is\n" +
+		"released or expires. Owners keep a lease alive with 'gwt lease heartbeat'.\n\n" +
+		"Leases are stored in gwt/leases.json in the repository's common git dir. The\n" +
+		"owner defaults to $GWT_LEASE_OWNER.",
+	Example: "  path=$(gwt lease acquire --new task-42 --ttl 2h --owner agent-7 --plain)\n" +
+		"  gwt lease heartbeat task-42 --owner agent-7\n" +
+		"  gwt lease release task-42 --owner agent-7",
+}
+
+var leaseAcquireCmd = &cobra.Command{
+	Use:   "acquire",
+	Short: "Lease a worktree (the current one, --branch or a --new one)",
+	Long: "Lease the worktree of --branch, a new worktree created with --new, or the current\n" +
+		"worktree, and print its path. Acquiring a lease you already hold renews it; a\n" +
+		"lease held by another owner can only be taken over once it has expired.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branch, _ := cmd.Flags().GetString("branch")
+		newName, _ := cmd.Flags().GetString("new")
+		from, _ := cmd.Flags().GetString("from")
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		owner, err := leaseOwner(cmd)
+		if err != nil {
+			return err
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("--ttl must be positive")
+		}
+		if branch != "" && newName != "" {
+			return fmt.Errorf("--branch and --new are mutually exclusive")
+		}
+		if cmd.Flags().Changed("from") && newName == "" {
+			return fmt.Errorf("--from requires --new")
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		if newName != "" {
+			if !cmd.Flags().Changed("from") {
+				if from, err = worktree.GetDefaultBranch(); err != nil {
+					return err
+				}
+			}
+			spec, err := worktree.ResolveCreateSpec(newName, from, false, false)
+			if err != nil {
+				return err
+			}
+			if spec.Mode != worktree.ModeNew {
+				return fmt.Errorf("branch '%s' already exists; lease its worktree with --branch", newName)
+			}
+			createFormat := format
+			if createFormat == outputFormatJSON {
+				createFormat = outputFormatPlain
+			}
+			if _, err := createWorktreeNonTUI(spec, false, false, createFormat, os.Stderr); err != nil {
+				return err
+			}
+			branch = spec.Branch
+		}
+
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		var targetArgs []string
+		if branch != "" {
+			targetArgs = []string{branch}
+		}
+		wt, err := statusTarget(worktrees, targetArgs)
+		if err != nil {
+			return err
+		}
+		if mainWT, _ := worktree.FindMainWorktree(); worktree.SamePath(wt.Path, mainWT) {
+			return fmt.Errorf("the main worktree cannot be leased")
+		}
+		commonGitDir, err := worktree.GetCommonGitDir(wt.Path)
+		if err != nil {
+			return err
+		}
+
+		var l lease.Lease
+		err = lease.Update(commonGitDir, func(s *lease.Set) error {
+			existing := s.Find(wt.Path)
+			switch {
+			case existing != nil && existing.Owner == owner:
+				existing.Branch = wt.Branch
+				existing.Renew(ttl)
+				l = *existing
+				// Re-apply the lock in case someone lifted it by hand.
+				_ = worktree.Lock(wt.Path, l.Reason())
+				return nil
+			case existing != nil && !existing.Expired(time.Now()):
+				return &lease.ErrLeased{Lease: *existing}
+			case existing != nil:
+				fmt.Fprintln(os.Stderr, infoStyle.Render(fmt.Sprintf("Note: taking over the expired lease of %s", existing.Owner)))
+				_ = worktree.Unlock(wt.Path)
+			}
+			l = lease.New(wt.Path, wt.Branch, owner, ttl)
+			if err := worktree.Lock(wt.Path, l.Reason()); err != nil {
+				return fmt.Errorf("%w (is it locked outside gwt? see 'git worktree unlock')", err)
+			}
+			s.Put(l)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case outputFormatJSON:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(leaseInfo{Lease: l})
+		case outputFormatPlain:
+			fmt.Println(l.Path)
+		default:
+			fmt.Fprintf(os.Stderr, "%s Leased %s to %s until %s\n", successStyle.Render("✓"), fileStyle.Render(leaseName(l)), l.Owner, l.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+			fmt.Println(l.Path)
+		}
+		return nil
+	},
+}
+
+var leaseHeartbeatCmd = &cobra.Command{
+	Use:   "heartbeat [branch]",
+	Short: "Extend a lease you hold",
+	Long: "Extend the lease on the worktree of branch (or the current worktree) by its TTL,\n" +
+		"or by --ttl. Fails if the lease is gone or held by another owner, in which case\n" +
+		"the caller should stop working in the worktree.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		owner, err := leaseOwner(cmd)
+		if err != nil {
+			return err
+		}
+		wt, commonGitDir, err := leaseTarget(args)
+		if err != nil {
+			return err
+		}
+		var l lease.Lease
+		err = lease.Update(commonGitDir, func(s *lease.Set) error {
+			existing := s.Find(wt.Path)
+			if existing == nil {
+				return fmt.Errorf("no lease on %s", wt.Path)
+			}
+			if existing.Owner != owner {
+				return &lease.ErrLeased{Lease: *existing}
+			}
+			ttl, _ := cmd.Flags().GetDuration("ttl")
+			if !cmd.Flags().Changed("ttl") {
+				if d, err := time.ParseDuration(existing.TTL); err == nil {
+					ttl = d
+				}
+			}
+			if ttl <= 0 {
+				return fmt.Errorf("--ttl must be positive")
+			}
+			existing.Renew(ttl)
+			l = *existing
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Lease extended until "+l.ExpiresAt.Local().Format("2006-01-02 15:04:05")))
+		return nil
+	},
+}
+
+var leaseReleaseCmd = &cobra.Command{
+	Use:   "release [branch]",
+	Short: "Release a lease and unlock the worktree",
+	Long: "Release the lease on the worktree of branch (or the current worktree) and lift its\n" +
+		"git lock. Only the owner can release a lease unless --force is given.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		owner := ""
+		if !force {
+			var err error
+			if owner, err = leaseOwner(cmd); err != nil {
+				return err
+			}
+		}
+		wt, commonGitDir, err := leaseTarget(args)
+		if err != nil {
+			return err
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		var l lease.Lease
+		err = lease.Update(commonGitDir, func(s *lease.Set) error {
+			existing := s.Find(wt.Path)
+			if existing == nil {
+				return fmt.Errorf("no lease on %s", wt.Path)
+			}
+			if !force && existing.Owner != owner {
+				return fmt.Errorf("%w; use --force to release it anyway", &lease.ErrLeased{Lease: *existing})
+			}
+			l = *existing
+			s.Remove(wt.Path)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := worktree.Unlock(wt.Path); err != nil {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: "+err.Error()))
+		}
+		fmt.Fprintf(os.Stderr, "%s Released %s\n", successStyle.Render("✓"), fileStyle.Render(leaseName(l)))
+		return nil
+	},
+}
+
+var leaseListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List leases and when they expire",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		commonGitDir, err := worktree.CurrentCommonGitDir()
+		if err != nil {
+			return err
+		}
+		s, err := lease.Load(commonGitDir)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		infos := make([]leaseInfo, 0, len(s.Leases))
+		for _, l := range s.Leases {
+			infos = append(infos, leaseInfo{Lease: l, Expired: l.Expired(now)})
+		}
+
+		switch format {
+		case outputFormatJSON:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(infos)
+		case outputFormatPlain:
+			for _, l := range infos {
+				fmt.Printf("%s\t%s\t%s\t%s\t%t\n", l.Branch, l.Owner, l.ExpiresAt.Format(time.RFC3339), l.Path, l.Expired)
+			}
+			return nil
+		}
+		if len(infos) == 0 {
+			fmt.Println(infoStyle.Render("No leases"))
+			return nil
+		}
+		for _, l := range infos {
+			state := "expires in " + l.ExpiresAt.Sub(now).Round(time.Second).String()
+			if l.Expired {
+				state = "expired " + now.Sub(l.ExpiresAt).Round(time.Second).String() + " ago"
+			}
+			fmt.Printf("%-28s %-16s %s\n", fileStyle.Render(leaseName(l.Lease)), l.Owner, infoStyle.Render(state))
+		}
+		return nil
+	},
+}
+
+// leaseOwner returns --owner, falling back to $GWT_LEASE_OWNER.
+func leaseOwner(cmd *cobra.Command) (string, error) {
+	owner, _ := cmd.Flags().GetString("owner")
+	if owner == "" {
+		owner = strings.TrimSpace(os.Getenv("GWT_LEASE_OWNER"))
+	}
+	if owner == "" {
+		return "", fmt.Errorf("--owner is required (or set GWT_LEASE_OWNER)")
+	}
+	return owner, nil
+}
+
+// leaseTarget resolves the worktree of a lease subcommand and its common git dir.
+func leaseTarget(args []string) (worktree.Worktree, string, error) {
+	worktrees, err := worktree.List()
+	if err != nil {
+		return worktree.Worktree{}, "", err
+	}
+	wt, err := statusTarget(worktrees, args)
+	if err != nil {
+		return wt, "", err
+	}
+	commonGitDir, err := worktree.GetCommonGitDir(wt.Path)
+	return wt, commonGitDir, err
+}
+
+func leaseName(l lease.Lease) string {
+	if l.Branch != "" {
+		return l.Branch
+	}
+	return l.Path
+}
+
+// describeLease summarizes an active lease for status output.
+func describeLease(l *lease.Lease) string {
+	return fmt.Sprintf("%s until %s", l.Owner, l.ExpiresAt.Local().Format("2006-01-02 15:04"))
+}
+
+func init() {
+	rootCmd.AddCommand(leaseCmd)
+	leaseCmd.AddCommand(leaseAcquireCmd, leaseHeartbeatCmd, leaseReleaseCmd, leaseListCmd)
+
+	leaseCmd.PersistentFlags().String("owner", "", "Lease owner, e.g. an agent or job id (env: GWT_LEASE_OWNER)")
+
+	leaseAcquireCmd.Flags().StringP("branch", "b", "", "Lease the worktree of this branch")
+	leaseAcquireCmd.Flags().String("new", "", "Create a new worktree with this branch name and lease it")
+	leaseAcquireCmd.Flags().StringP("from", "f", "", "Base for --new (defaults to the default branch)")
+	leaseAcquireCmd.Flags().Duration("ttl", time.Hour, "How long the lease lasts without a heartbeat")
+	leaseAcquireCmd.Flags().Bool("plain", false, "Print only the worktree path")
+	leaseAcquireCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+
+	leaseHeartbeatCmd.Flags().Duration("ttl", 0, "New lease duration (defaults to the lease's TTL)")
+
+	leaseReleaseCmd.Flags().Bool("force", false, "Release a lease held by another owner")
+
+	leaseListCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	leaseListCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/forge"
 	"github.com/nachoal/gwt/internal/ui"
 	"github.com/nachoal/gwt/internal/worktree"
 	"github.com/spf13/cobra"
@@ -22,6 +25,8 @@ var listCmd = &cobra.Command{
 		noTUI, _ := cmd.Flags().GetBool("no-tui")
 		plain, _ := cmd.Flags().GetBool("plain")
 		jsonOut, _ := cmd.Flags().GetBool("json")
+		withPR, _ := cmd.Flags().GetBool("pr")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
 
 		format, err := resolveOutputFormat(plain, jsonOut)
 		if err != nil {
@@ -29,14 +34,29 @@ var listCmd = &cobra.Command{
 		}
 
 		if rootMode {
+			if len(tags) > 0 {
+				return fmt.Errorf("--tag cannot be combined with --root")
+			}
 			return listFromRoot(overridePath, format)
 		}
 
+		var provider forge.Provider
+		if withPR {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return err
+			}
+			provider = forgeProvider(cfg)
+			if provider == nil {
+				fmt.Fprintln(os.Stderr, infoStyle.Render("Note: no forge configured (settings.forge); no pull requests to show"))
+			}
+		}
+
 		useTUI := !noTUI && format == outputFormatPretty && hasInteractiveTTY()
 		if useTUI {
 			// Default interactive mode for humans.
 			// Render UI to stderr so stdout can carry the selected path (shell integration).
-			p := tea.NewProgram(ui.NewListModel(), tea.WithInputTTY(), tea.WithOutput(os.Stderr))
+			p := tea.NewProgram(ui.NewListModel(registryRoot(), tags, provider), tea.WithInputTTY(), tea.WithOutput(os.Stderr))
 			m, err := p.Run()
 			if err != nil {
 				return err
@@ -52,7 +72,7 @@ var listCmd = &cobra.Command{
 			return nil
 		}
 
-		return listCurrentRepo(format)
+		return listCurrentRepo(format, withPR, provider, tags)
 	},
 }
 
@@ -63,14 +83,34 @@ func init() {
 	listCmd.Flags().Bool("no-tui", false, "Run without the interactive list UI (auto-enabled when no interactive TTY is available)")
 	listCmd.Flags().Bool("plain", false, "Plain text output without styling")
 	listCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+	listCmd.Flags().StringSlice("tag", nil, "Only show worktrees with this tag (repeatable; all must match)")
+	listCmd.Flags().Bool("pr", false, "Look up each branch's pull request on the configured forge (settings.forge)")
 }
 
-func listCurrentRepo(format outputFormat) error {
-	results, err := worktree.List()
+// listItem is a worktree as reported by `gwt list`.
+type listItem struct {
+	worktree.Worktree
+	PR       *forge.PullRequest `json:"pr,omitempty"`
+	Metadata *worktree.Metadata `json:"metadata,omitempty"`
+}
+
+// listCurrentRepo prints the repository's worktrees; withPR adds a PR column
+// filled from provider, which may be nil.
+func listCurrentRepo(format outputFormat, withPR bool, provider forge.Provider, tags []string) error {
+	worktrees, err := worktree.ListAll()
 	if err != nil {
 		return err
 	}
 
+	results := make([]listItem, 0, len(worktrees))
+	for _, wt := range worktrees {
+		meta, _ := worktree.ReadMetadata(wt.Path)
+		if !meta.HasTags(tags) {
+			continue
+		}
+		results = append(results, listItem{Worktree: wt, PR: lookupPullRequest(provider, wt.Branch), Metadata: meta})
+	}
+
 	if len(results) == 0 {
 		switch format {
 		case outputFormatPretty:
@@ -93,27 +133,47 @@ func listCurrentRepo(format outputFormat) error {
 
 	maxBranch := 6 // "Branch"
 	for _, r := range results {
-		if l := len(r.Branch); l > maxBranch {
+		if l := len(displayBranch(r.Worktree)); l > maxBranch {
 			maxBranch = l
 		}
 	}
 
 	header := fmt.Sprintf("%-*s  %-7s  %s", maxBranch, "Branch", "HEAD", "Path")
+	if withPR {
+		header = fmt.Sprintf("%-*s  %-7s  %-12s  %s", maxBranch, "Branch", "HEAD", "PR", "Path")
+	}
 	if format == outputFormatPretty {
 		fmt.Println(titleStyle.Render("Worktrees"))
 		fmt.Println(infoStyle.Render(header))
 	}
 	if format == outputFormatPlain {
-		fmt.Println("branch\thead\tpath")
+		if withPR {
+			fmt.Println("branch\thead\tpr\tpath\tlocked")
+		} else {
+			fmt.Println("branch\thead\tpath\tlocked")
+		}
 	}
 
 	for _, r := range results {
 		if format == outputFormatPretty {
-			line := fmt.Sprintf("%-*s  %-7s  %s", maxBranch, r.Branch, r.Head, r.Path)
+			line := fmt.Sprintf("%-*s  %-7s  %s", maxBranch, displayBranch(r.Worktree), r.Head, r.Path)
+			if withPR {
+				line = fmt.Sprintf("%-*s  %-7s  %-12s  %s", maxBranch, displayBranch(r.Worktree), r.Head, r.PR.Label(), r.Path)
+			}
+			if state := describeWorktreeState(r.Worktree); state != "" {
+				line += "  " + lockedStyle.Render(state)
+			}
+			if label := r.Metadata.Label(); label != "" {
+				line += "  " + infoStyle.Render(label)
+			}
 			fmt.Println(line)
 		}
 		if format == outputFormatPlain {
-			fmt.Printf("%s\t%s\t%s\n", r.Branch, r.Head, r.Path)
+			if withPR {
+				fmt.Printf("%s\t%s\t%s\t%s\t%t\n", r.Branch, r.Head, r.PR.Label(), r.Path, r.Locked)
+			} else {
+				fmt.Printf("%s\t%s\t%s\t%t\n", r.Branch, r.Head, r.Path, r.Locked)
+			}
 		}
 	}
 
@@ -176,6 +236,9 @@ func listFromRoot(override string, format outputFormat) error {
 	for _, r := range results {
 		if format == outputFormatPretty {
 			line := fmt.Sprintf("%-*s  %-*s  %-7s  %s", maxProj, r.Project, maxBranch, r.Branch, r.Head, r.Path)
+			if r.Orphaned {
+				line += "  " + lockedStyle.Render("orphaned (see 'gwt prune')")
+			}
 			fmt.Println(line)
 		}
 		if format == outputFormatPlain {
@@ -184,3 +247,35 @@ func listFromRoot(override string, format outputFormat) error {
 	}
 	return nil
 }
+
+// displayBranch is the branch column of a listing.
+func displayBranch(wt worktree.Worktree) string {
+	switch {
+	case wt.Bare:
+		return "(bare)"
+	case wt.Detached:
+		return "(detached)"
+	}
+	return wt.Branch
+}
+
+// lockedStyle marks locked and prunable worktrees.
+var lockedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+// describeWorktreeState flags locked and prunable worktrees in listings.
+func describeWorktreeState(wt worktree.Worktree) string {
+	switch {
+	case wt.Prunable:
+		return "prunable" + describePrunableReason(wt)
+	case wt.Locked:
+		return "locked" + describeLockReason(wt)
+	}
+	return ""
+}
+
+func describePrunableReason(wt worktree.Worktree) string {
+	if wt.PrunableReason == "" {
+		return ""
+	}
+	return " (" + wt.PrunableReason + ")"
+}
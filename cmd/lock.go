@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nachoal/gwt/internal/lease"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock <branch>",
+	Short: "Lock a worktree so it is not removed or pruned",
+	Long: "Lock the worktree of branch with 'git worktree lock'. Locked worktrees are kept\n" +
+		"by 'git worktree prune' and 'gwt clean', and 'gwt remove' refuses them.\n" +
+		"Useful for worktrees on removable or network drives.",
+	Example: "  gwt lock feature/foo --reason \"on the external drive\"",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reason, _ := cmd.Flags().GetString("reason")
+
+		// Look the worktree up under the lock, so that a concurrent
+		// 'gwt lease acquire' cannot lock it in between.
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		wt, err := lockTarget(args[0])
+		if err != nil {
+			return err
+		}
+		if wt.Locked {
+			return fmt.Errorf("worktree for branch '%s' is already locked%s", args[0], describeLockReason(wt))
+		}
+
+		if err := worktree.Lock(wt.Path, reason); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, successStyle.Render("✓")+" Locked "+fileStyle.Render(args[0]))
+		return nil
+	},
+}
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock <branch>",
+	Short: "Unlock a worktree locked with 'gwt lock'",
+	Long: "Lift the 'git worktree lock' of the worktree of branch. A worktree locked by a\n" +
+		"lease is unlocked with 'gwt lease release' instead.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		wt, err := lockTarget(args[0])
+		if err != nil {
+			return err
+		}
+		if !wt.Locked {
+			return fmt.Errorf("worktree for branch '%s' is not locked", args[0])
+		}
+		if l := worktree.ActiveLease(wt.Path); l != nil {
+			return fmt.Errorf("worktree for branch '%s' is leased by %s; use 'gwt lease release'", args[0], describeLease(l))
+		}
+
+		if err := worktree.Unlock(wt.Path); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, successStyle.Render("✓")+" Unlocked "+fileStyle.Render(args[0]))
+		return nil
+	},
+}
+
+func lockTarget(branch string) (worktree.Worktree, error) {
+	worktrees, err := worktree.List()
+	if err != nil {
+		return worktree.Worktree{}, err
+	}
+	wt, err := statusTarget(worktrees, []string{branch})
+	if err != nil {
+		return wt, err
+	}
+	if mainWT, _ := worktree.FindMainWorktree(); worktree.SamePath(wt.Path, mainWT) {
+		return wt, fmt.Errorf("the main worktree cannot be locked")
+	}
+	return wt, nil
+}
+
+// checkUnlocked refuses a worktree that 'gwt remove' and 'gwt done' must
+// leave alone: one under an active lease or locked with 'gwt lock'. The lock
+// of an expired lease does not count; removing the worktree lifts it.
+func checkUnlocked(wt worktree.Worktree) error {
+	if l := worktree.RecordedLease(wt.Path); l != nil {
+		if !l.Expired(time.Now()) {
+			return &lease.ErrLeased{Lease: *l}
+		}
+		if wt.LockReason == l.Reason() {
+			return nil
+		}
+	}
+	if !wt.Locked {
+		return nil
+	}
+	if wt.LockReason != "" {
+		return fmt.Errorf("worktree '%s' is locked: %s; run 'gwt unlock %s' first", wt.Name(), wt.LockReason, wt.Name())
+	}
+	return fmt.Errorf("worktree '%s' is locked; run 'gwt unlock %s' first", wt.Name(), wt.Name())
+}
+
+// describeLockReason formats a worktree's lock reason as a suffix.
+func describeLockReason(wt worktree.Worktree) string {
+	if wt.LockReason == "" {
+		return ""
+	}
+	return " (" + wt.LockReason + ")"
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd, unlockCmd)
+	lockCmd.Flags().String("reason", "", "Why the worktree is locked (shown by 'gwt list')")
+}
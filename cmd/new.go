@@ -2,47 +2,76 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/journal"
 	"github.com/nachoal/gwt/internal/ui"
 	"github.com/nachoal/gwt/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
 var newCmd = &cobra.Command{
-	Use:   "new <branch-name>",
+	Use:   "new <branch-name | remote/branch | ref>",
 	Short: "Create a new worktree",
 	Long: "Create a new worktree for the current project.\n\n" +
-		"Tip: With shell integration enabled (eval \"$(gwt shell)\"), you can use:\n" +
-		"  • gwt new <branch> -c                # cd into the new worktree and run your 'claude' alias\n" +
-		"  • gwt new <branch> -c \"prompt\"     # run 'claude \"prompt\"'\n" +
-		"  • gwt new <branch> -c issue <url>   # run 'claude \"/issue-analysis <url>\"'\n\n" +
-		"Note: -c is provided by the shell wrapper, not by the gwt binary.",
+		"Launch a coding agent in the new worktree (see 'gwt agent'):\n" +
+		"  • gwt new <branch> --agent codex --prompt \"...\"  # any agent from the agents: config\n" +
+		"  • gwt new <branch> --prompt \"...\"                # the default agent (settings.agent, or claude)\n" +
+		"  • gwt new <branch> --agent default --issue <url>  # prompt from the agent's issue_prompt\n\n" +
+		"With shell integration (eval \"$(gwt shell)\"), -c is shorthand for the default agent:\n" +
+		"  • gwt new <branch> -c [\"prompt\"]  or  gwt new <branch> -c issue <url>\n\n" +
+		"Refs that are not known locally are fetched from the remote first:\n" +
+		"  • gwt new origin/their-branch        # local branch 'their-branch' tracking origin\n" +
+		"  • gwt new their-branch --track       # same, using the default remote\n" +
+		"  • gwt new --detach v1.2.3            # read-only investigation at a tag or commit\n" +
+		"  • gwt new fix/foo --from <sha>       # new branch from any commit",
 	Example: "  gwt new feature/foo\n" +
 		"  gwt new feature/foo -f develop\n" +
+		"  gwt new origin/teammate-branch\n" +
+		"  gwt new --detach v1.2.3\n" +
+		"  gwt new fix/bug --agent codex --prompt \"fix the flaky test\"\n" +
 		"  # With shell integration:\n" +
 		"  gwt new fix/bug -c issue https://example/issue/123\n",
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		branchName := args[0]
+		target := args[0]
 		fromBranch, _ := cmd.Flags().GetString("from")
-		verbose, _ := cmd.Flags().GetBool("verbose")
-		timed, _ := cmd.Flags().GetBool("timed")
-		noTUI, _ := cmd.Flags().GetBool("no-tui")
-		plain, _ := cmd.Flags().GetBool("plain")
-		jsonOut, _ := cmd.Flags().GetBool("json")
+		detach, _ := cmd.Flags().GetBool("detach")
+		track, _ := cmd.Flags().GetBool("track")
 
-		format, err := resolveOutputFormat(plain, jsonOut)
+		opts, err := createOptionsFromFlags(cmd)
 		if err != nil {
 			return err
 		}
-		if format == outputFormatJSON && (verbose || timed) {
-			return fmt.Errorf("--json cannot be combined with --verbose or --timed")
+		opts.note, _ = cmd.Flags().GetString("note")
+		opts.issue, _ = cmd.Flags().GetString("issue")
+		if detach && (track || cmd.Flags().Changed("from")) {
+			return fmt.Errorf("--detach cannot be combined with --track or --from")
 		}
 
+		multiplexer, err := sessionFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		openAfter, _ := cmd.Flags().GetBool("open")
+		agentName, _ := cmd.Flags().GetString("agent")
+		prompt, _ := cmd.Flags().GetString("prompt")
+		launch := cmd.Flags().Changed("agent") || prompt != ""
+		if launch && multiplexer != "" {
+			return fmt.Errorf("--agent cannot be combined with --tmux or --zellij; add the agent to session.windows instead")
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
 		// If no from branch specified, auto-detect the default branch
 		if cmd.Flags().Changed("from") == false {
 			defaultBranch, err := worktree.GetDefaultBranch()
@@ -51,32 +80,139 @@ var newCmd = &cobra.Command{
 			}
 		}
 
-		useTUI := !noTUI &&
-			format == outputFormatPretty &&
-			!verbose &&
-			!timed &&
-			hasInteractiveTTY()
+		spec, err := worktree.ResolveCreateSpec(target, fromBranch, detach, track)
+		if err != nil {
+			return err
+		}
+		path, err := runCreate(spec, opts)
+		if err != nil || (multiplexer == "" && !openAfter && !launch) {
+			return err
+		}
+		// Editors, agents and sessions can block for long; don't hold the lock meanwhile.
+		release()
+		if openAfter {
+			if err := openEditor(path, ""); err != nil {
+				return err
+			}
+		}
+		if launch {
+			err := launchAgent(agentName, prompt, opts.issue, path, spec.Branch)
+			var exitErr *ExitCodeError
+			if errors.As(err, &exitErr) {
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+			}
+			return err
+		}
+		if multiplexer != "" {
+			return openSession(multiplexer, path, spec.Name)
+		}
+		return nil
+	},
+}
+
+// createOptions are the output flags shared by commands that create worktrees,
+// plus the note and issue URL to store in the new worktree's metadata.
+type createOptions struct {
+	verbose   bool
+	timed     bool
+	noTUI     bool
+	printPath bool
+	format    outputFormat
+	note      string
+	issue     string
+}
+
+func addCreateFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("verbose", "v", false, "Verbose output for setup commands (stream stdout/stderr)")
+	cmd.Flags().BoolP("timed", "t", false, "Print each setup command and how long it took")
+	cmd.Flags().Bool("no-tui", false, "Run without the TUI (auto-enabled when no interactive TTY is available)")
+	cmd.Flags().Bool("plain", false, "Plain text output without styling")
+	cmd.Flags().Bool("json", false, "Machine-readable JSON output")
+	cmd.Flags().Bool("print-path", false, "Print only the worktree path to stdout on success")
+	_ = cmd.Flags().MarkHidden("print-path")
+}
+
+func createOptionsFromFlags(cmd *cobra.Command) (createOptions, error) {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	timed, _ := cmd.Flags().GetBool("timed")
+	noTUI, _ := cmd.Flags().GetBool("no-tui")
+	plain, _ := cmd.Flags().GetBool("plain")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	printPath, _ := cmd.Flags().GetBool("print-path")
 
-		// If non-interactive (or explicitly disabled), run the non-TUI flow.
-		if !useTUI {
-			return createWorktreeNonTUI(branchName, fromBranch, verbose, timed, format)
+	format, err := resolveOutputFormat(plain, jsonOut)
+	if err != nil {
+		return createOptions{}, err
+	}
+	if format == outputFormatJSON && (verbose || timed) {
+		return createOptions{}, fmt.Errorf("--json cannot be combined with --verbose or --timed")
+	}
+	if format == outputFormatJSON && printPath {
+		return createOptions{}, fmt.Errorf("--json cannot be combined with --print-path")
+	}
+	return createOptions{verbose: verbose, timed: timed, noTUI: noTUI, printPath: printPath, format: format}, nil
+}
+
+// runCreate creates and provisions the worktree described by spec, using the
+// TUI when interactive, and returns its path if it was created. The caller
+// must hold the repository lock.
+func runCreate(spec worktree.CreateSpec, opts createOptions) (string, error) {
+	useTUI := !opts.noTUI &&
+		opts.format == outputFormatPretty &&
+		!opts.verbose &&
+		!opts.timed &&
+		hasInteractiveTTY()
+
+	// If non-interactive (or explicitly disabled), run the non-TUI flow.
+	if !useTUI {
+		out := io.Writer(os.Stdout)
+		if opts.printPath {
+			out = os.Stderr
+		}
+		path, err := createWorktreeNonTUI(spec, opts.verbose, opts.timed, opts.format, out)
+		if path != "" {
+			recordNote(path, opts)
 		}
+		if err == nil && opts.printPath {
+			fmt.Println(path)
+		}
+		return path, err
+	}
 
-		// Otherwise, run the TUI flow (render to stderr to keep stdout script-friendly).
-		p := tea.NewProgram(ui.NewCreateModel(branchName, fromBranch), tea.WithOutput(os.Stderr))
-		_, err = p.Run()
-		return err
-	},
+	// Otherwise, run the TUI flow (render to stderr to keep stdout script-friendly).
+	commonGitDir, _ := worktree.CurrentCommonGitDir()
+	branchBefore := worktree.BranchSHA(commonGitDir, spec.Branch)
+	p := tea.NewProgram(ui.NewCreateModel(spec), tea.WithOutput(os.Stderr))
+	m, err := p.Run()
+
+	// The worktree may exist even if a later step (e.g. setup) failed.
+	type worktreePathModel interface{ WorktreePath() string }
+	path := ""
+	if wp, ok := m.(worktreePathModel); ok {
+		path = wp.WorktreePath()
+	}
+	if path != "" {
+		recordCreateJournal(commonGitDir, spec, branchBefore, path)
+		recordCreateMetadata(spec, path)
+		recordNote(path, opts)
+	}
+	if err == nil && opts.printPath && path != "" {
+		fmt.Println(path)
+	}
+	return path, err
 }
 
 func init() {
 	rootCmd.AddCommand(newCmd)
-	newCmd.Flags().StringP("from", "f", "", "Base branch to create worktree from (auto-detected if not specified)")
-	newCmd.Flags().BoolP("verbose", "v", false, "Verbose output for setup commands (stream stdout/stderr)")
-	newCmd.Flags().BoolP("timed", "t", false, "Print each setup command and how long it took")
-	newCmd.Flags().Bool("no-tui", false, "Run without the TUI (auto-enabled when no interactive TTY is available)")
-	newCmd.Flags().Bool("plain", false, "Plain text output without styling")
-	newCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+	newCmd.Flags().StringP("from", "f", "", "Base branch, tag or commit to create the new branch from (auto-detected if not specified)")
+	newCmd.Flags().Bool("detach", false, "Check out a tag, commit or ref with a detached HEAD instead of a branch")
+	newCmd.Flags().String("note", "", "Note to store in the worktree's metadata (see 'gwt note')")
+	newCmd.Flags().String("issue", "", "Issue URL to store in the worktree's metadata")
+	newCmd.Flags().Bool("track", false, "Create a local branch tracking the same-named branch on the default remote")
+	addCreateFlags(newCmd)
+	addSessionFlags(newCmd)
+	newCmd.Flags().Bool("open", false, "Open the new worktree in settings.editor (see 'gwt open')")
+	addAgentFlags(newCmd)
 }
 
 type createResult struct {
@@ -84,100 +220,205 @@ type createResult struct {
 	Branch string `json:"branch"`
 	From   string `json:"from"`
 	Path   string `json:"path"`
+	// Mode is one of "new", "existing", "track" or "detach".
+	Mode  string         `json:"mode"`
+	Ports map[string]int `json:"ports,omitempty"`
+	// ComposeProject is the COMPOSE_PROJECT_NAME of the worktree's stack.
+	ComposeProject string `json:"compose_project,omitempty"`
 }
 
-func createWorktreeNonTUI(branchName, fromBranch string, verbose, timed bool, format outputFormat) error {
+// createWorktreeNonTUI creates and provisions a worktree, writing progress to w,
+// and returns the new worktree's path.
+func createWorktreeNonTUI(spec worktree.CreateSpec, verbose, timed bool, format outputFormat, w io.Writer) (string, error) {
 	if format == outputFormatPretty {
-		fmt.Println(titleStyle.Render("Creating worktree (non-TUI)"))
+		fmt.Fprintln(w, titleStyle.Render("Creating worktree (non-TUI)"))
 	}
 	if format == outputFormatPlain {
-		fmt.Println("Creating worktree")
+		fmt.Fprintln(w, "Creating worktree")
 	}
 
 	// Step 1: Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Step 2: Determine project and target path
-	projectName, err := worktree.GetProjectName()
+	projectName, err := worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+	if err != nil {
+		return "", err
+	}
+	targetPath, err := worktree.RenderWorktreePath(cfg.Settings.PathTemplate, cfg.Settings.Root, projectName, spec.Name)
 	if err != nil {
-		return err
+		return "", err
 	}
-	targetPath := worktree.GetWorktreePath(cfg.Settings.Root, projectName, branchName)
 	if format == outputFormatPretty {
-		fmt.Printf("→ Project: %s\n", projectName)
-		fmt.Printf("→ From: %s\n", fromBranch)
-		fmt.Printf("→ Path: %s\n", targetPath)
+		fmt.Fprintf(w, "→ Project: %s\n", projectName)
+		fmt.Fprintf(w, "→ From: %s (%s)\n", spec.Ref, spec.Mode)
+		fmt.Fprintf(w, "→ Path: %s\n", targetPath)
 	}
 	if format == outputFormatPlain {
-		fmt.Printf("project=%s\n", projectName)
-		fmt.Printf("from=%s\n", fromBranch)
-		fmt.Printf("path=%s\n", targetPath)
+		fmt.Fprintf(w, "project=%s\n", projectName)
+		fmt.Fprintf(w, "from=%s\n", spec.Ref)
+		fmt.Fprintf(w, "mode=%s\n", spec.Mode)
+		fmt.Fprintf(w, "path=%s\n", targetPath)
 	}
 
 	// Step 3: Create worktree
-	if err := worktree.Create(branchName, fromBranch, targetPath); err != nil {
-		return err
+	commonGitDir, _ := worktree.CurrentCommonGitDir()
+	branchBefore := worktree.BranchSHA(commonGitDir, spec.Branch)
+	if err := worktree.CreateAt(spec, targetPath); err != nil {
+		return "", err
+	}
+	recordCreateJournal(commonGitDir, spec, branchBefore, targetPath)
+	recordCreateMetadata(spec, targetPath)
+	if err := worktree.Register(cfg.Settings.Root, projectName, spec.Name, targetPath); err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not record worktree in registry: "+err.Error()))
 	}
 	if format == outputFormatPretty {
-		fmt.Println(successStyle.Render("✓ Worktree created"))
+		fmt.Fprintln(w, successStyle.Render("✓ Worktree created"))
 	}
 	if format == outputFormatPlain {
-		fmt.Println("worktree_created=true")
+		fmt.Fprintln(w, "worktree_created=true")
 	}
 
 	// Step 4: Copy files
 	mainPath, err := os.Getwd()
 	if err != nil {
-		return err
+		return "", err
 	}
 	if err := worktree.CopyFiles(mainPath, targetPath, cfg.Copy); err != nil {
-		return err
+		return "", err
 	}
 	if format == outputFormatPretty {
-		fmt.Println(successStyle.Render("✓ Files copied"))
+		fmt.Fprintln(w, successStyle.Render("✓ Files copied"))
 	}
 	if format == outputFormatPlain {
-		fmt.Println("files_copied=true")
+		fmt.Fprintln(w, "files_copied=true")
+	}
+
+	// Step 4b: Reserve ports
+	ports, err := worktree.AssignPorts(cfg.Settings.Root, targetPath, cfg.Ports)
+	if err != nil {
+		return "", err
+	}
+	if len(ports) > 0 {
+		if format == outputFormatPretty {
+			fmt.Fprintln(w, successStyle.Render("✓ Ports reserved: ")+worktree.FormatPorts(ports))
+		}
+		if format == outputFormatPlain {
+			fmt.Fprintf(w, "ports=%s\n", worktree.FormatPorts(ports))
+		}
+	}
+
+	// Step 4c: Render templates
+	if len(cfg.Templates) > 0 {
+		data := worktree.NewTemplateData(spec, projectName, targetPath, mainPath, ports)
+		if err := worktree.RenderTemplates(mainPath, targetPath, cfg.Templates, data); err != nil {
+			return "", err
+		}
+		if format == outputFormatPretty {
+			fmt.Fprintln(w, successStyle.Render("✓ Templates rendered"))
+		}
+		if format == outputFormatPlain {
+			fmt.Fprintln(w, "templates_rendered=true")
+		}
+	}
+
+	// Step 4d: Start compose services
+	stack, err := worktree.StartCompose(cfg.Compose, projectName, spec.Name, targetPath, ports)
+	if err != nil {
+		return "", err
+	}
+	if stack != nil {
+		if format == outputFormatPretty {
+			fmt.Fprintln(w, successStyle.Render("✓ Services started: ")+stack.Project)
+		}
+		if format == outputFormatPlain {
+			fmt.Fprintf(w, "compose_project=%s\n", stack.Project)
+		}
 	}
 
 	// Step 5: Run setup commands
 	if len(cfg.Setup) > 0 {
-		out := os.Stdout
+		out := w
 		if format == outputFormatJSON {
 			out = nil
 		} else if format == outputFormatPretty {
-			fmt.Println(infoStyle.Render("Running setup commands:"))
+			fmt.Fprintln(w, infoStyle.Render("Running setup commands:"))
 		} else if format == outputFormatPlain {
-			fmt.Println("running_setup=true")
+			fmt.Fprintln(w, "running_setup=true")
 		}
 		if err := worktree.RunSetupCommandsOpts(targetPath, cfg.Setup, verbose, timed, out); err != nil {
-			return err
+			return "", err
 		}
 	}
 
 	switch format {
 	case outputFormatPretty:
-		fmt.Println(successStyle.Render("✓ Done"))
-		fmt.Println(fileStyle.Render(targetPath))
+		fmt.Fprintln(w, successStyle.Render("✓ Done"))
+		fmt.Fprintln(w, fileStyle.Render(targetPath))
 	case outputFormatPlain:
-		fmt.Println("status=ok")
-		fmt.Printf("path=%s\n", targetPath)
+		fmt.Fprintln(w, "status=ok")
+		fmt.Fprintf(w, "path=%s\n", targetPath)
 	case outputFormatJSON:
 		result := createResult{
 			Status: "ok",
-			Branch: branchName,
-			From:   fromBranch,
+			Branch: spec.Branch,
+			From:   spec.Ref,
 			Path:   targetPath,
+			Mode:   spec.Mode,
+			Ports:  ports,
+		}
+		if stack != nil {
+			result.ComposeProject = stack.Project
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		if err := enc.Encode(result); err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	return nil
+	return targetPath, nil
+}
+
+// recordCreateJournal journals a newly created worktree. branchBefore is the
+// branch SHA before creation, or "" if gwt created the branch.
+func recordCreateJournal(commonGitDir string, spec worktree.CreateSpec, branchBefore, path string) {
+	entry := journal.New("new", []string{spec.Name})
+	entry.AddBranch(spec.Branch, branchBefore, worktree.BranchSHA(commonGitDir, spec.Branch))
+	entry.AddWorktree(journal.ActionAdded, path, spec.Branch, worktree.HeadSHA(path))
+	recordJournal(commonGitDir, entry)
+}
+
+// recordCreateMetadata stores the base branch and provenance of a new worktree.
+func recordCreateMetadata(spec worktree.CreateSpec, path string) {
+	// Keep a compose stack the create flow may already have recorded.
+	err := worktree.UpdateMetadata(path, func(m *worktree.Metadata) {
+		stack := m.Compose
+		*m = *worktree.NewMetadata(spec, getVersionInfo().Version)
+		m.Compose = stack
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not record worktree metadata: "+err.Error()))
+	}
+}
+
+// recordNote stores the --note and --issue values of a create command.
+func recordNote(path string, opts createOptions) {
+	if opts.note == "" && opts.issue == "" {
+		return
+	}
+	err := worktree.UpdateMetadata(path, func(m *worktree.Metadata) {
+		if opts.note != "" {
+			m.Description = opts.note
+		}
+		if opts.issue != "" {
+			m.IssueURL = opts.issue
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not record worktree metadata: "+err.Error()))
+	}
 }
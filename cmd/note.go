@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note <branch> [text...]",
+	Short: "Show or set the note on a worktree",
+	Long: "Show or set the free-form note (description) stored in a worktree's metadata.\n" +
+		"Notes show up in `gwt status`, the list TUI and `gwt list --json`.",
+	Example: "  gwt note feature/foo \"waiting on API review\"\n" +
+		"  gwt note feature/foo --issue https://github.com/org/repo/issues/42\n" +
+		"  gwt note feature/foo --clear",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clear, _ := cmd.Flags().GetBool("clear")
+		issue, _ := cmd.Flags().GetString("issue")
+		branch, text := args[0], strings.TrimSpace(strings.Join(args[1:], " "))
+		if clear && text != "" {
+			return fmt.Errorf("--clear cannot be combined with a note")
+		}
+
+		path, err := worktreePathForBranch(branch)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			return fmt.Errorf("worktree for branch '%s' not found", branch)
+		}
+
+		if !clear && text == "" && !cmd.Flags().Changed("issue") {
+			m, err := worktree.ReadMetadata(path)
+			if err != nil {
+				return err
+			}
+			if m != nil && m.Description != "" {
+				fmt.Println(m.Description)
+			}
+			if m != nil && m.IssueURL != "" {
+				fmt.Println(m.IssueURL)
+			}
+			return nil
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return worktree.UpdateMetadata(path, func(m *worktree.Metadata) {
+			if clear {
+				m.Description = ""
+			}
+			if text != "" {
+				m.Description = text
+			}
+			if cmd.Flags().Changed("issue") {
+				m.IssueURL = issue
+			}
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(noteCmd)
+	noteCmd.Flags().Bool("clear", false, "Remove the note")
+	noteCmd.Flags().String("issue", "", "Set the issue URL (empty to remove it)")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/editor"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open [branch]",
+	Short: "Open a worktree in your editor or IDE",
+	Long: "Open the worktree for branch, or the current worktree, in settings.editor:\n" +
+		"code, cursor, zed, idea, nvim, vim, or any command line (use {{.Path}} to place\n" +
+		"the path; it is appended otherwise). Without settings.editor, $VISUAL and then\n" +
+		"$EDITOR are used.\n\n" +
+		"settings.editor_window (new or reuse) picks a new window or the last one for\n" +
+		"editors that support it; --new-window and --reuse-window override it.",
+	Example: "  gwt open feature/foo\n" +
+		"  gwt open --new-window\n" +
+		"  # .worktree.yaml: settings.editor: \"subl -n {{.Path}}\"",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		window, err := editorWindowFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		wt, err := statusTarget(worktrees, args)
+		if err != nil {
+			return err
+		}
+		return openEditor(wt.Path, window)
+	},
+}
+
+// editorWindowFromFlags returns the window mode chosen by --new-window or
+// --reuse-window, or "" to use settings.editor_window.
+func editorWindowFromFlags(cmd *cobra.Command) (string, error) {
+	newWindow, _ := cmd.Flags().GetBool("new-window")
+	reuseWindow, _ := cmd.Flags().GetBool("reuse-window")
+	switch {
+	case newWindow && reuseWindow:
+		return "", fmt.Errorf("--new-window and --reuse-window are mutually exclusive")
+	case newWindow:
+		return editor.WindowNew, nil
+	case reuseWindow:
+		return editor.WindowReuse, nil
+	}
+	return "", nil
+}
+
+// openEditor opens path in the configured editor and waits for it, which for
+// GUI editors returns right away. window overrides settings.editor_window.
+func openEditor(path, window string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if window == "" {
+		window = cfg.Settings.EditorWindow
+	}
+	c, err := editor.Command(cfg.Settings.Editor, window, path)
+	if err != nil {
+		return err
+	}
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// The shell integration captures stdout; terminal editors need the terminal.
+	if !isTTY(os.Stdout) {
+		c.Stdout = os.Stderr
+	}
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", c.Path, err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+	openCmd.Flags().Bool("new-window", false, "Open in a new editor window")
+	openCmd.Flags().Bool("reuse-window", false, "Reuse the last editor window")
+}
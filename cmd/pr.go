@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/journal"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr <number>",
+	Short: "Check out a pull/merge request into a worktree",
+	Long: "Fetch a GitHub pull request (refs/pull/<n>/head) or GitLab merge request\n" +
+		"(refs/merge-requests/<n>/head) from the remote and check it out into a worktree\n" +
+		"named pr/<n>-<slug>, running the usual copy and setup steps. No forge API is used.\n\n" +
+		"If a worktree for the pull request already exists, it is fast-forwarded to the\n" +
+		"latest head instead (use --force when the head was force-pushed).",
+	Example: "  gwt pr 123\n" +
+		"  gwt pr 123 --remote upstream\n" +
+		"  gwt pr 123 --slug fix-login\n",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || number <= 0 {
+			return fmt.Errorf("invalid pull request number '%s'", args[0])
+		}
+		remote, _ := cmd.Flags().GetString("remote")
+		slug, _ := cmd.Flags().GetString("slug")
+		force, _ := cmd.Flags().GetBool("force")
+
+		opts, err := createOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		if remote == "" {
+			remote = worktree.DefaultRemote()
+		}
+		head, err := worktree.FetchPullRequest(remote, number)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, infoStyle.Render(fmt.Sprintf("Fetched %s #%d from %s at %s: ", head.Forge, number, remote, shortSHA(head.SHA)))+head.Subject)
+
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		for _, wt := range worktrees {
+			if worktree.IsPullRequestBranch(wt.Branch, number) {
+				return refreshPullRequest(wt, head, force, opts)
+			}
+		}
+
+		if slug == "" {
+			slug = head.Subject
+		}
+		branch := worktree.PullRequestBranch(number, slug)
+		spec := worktree.CreateSpec{Mode: worktree.ModeNew, Name: branch, Branch: branch, Ref: head.SHA}
+
+		// A previous checkout may have left the branch behind without a worktree.
+		if existing := worktree.FindPullRequestBranch(number); existing != "" {
+			commonGitDir, err := worktree.CurrentCommonGitDir()
+			if err != nil {
+				return err
+			}
+			current := worktree.BranchSHA(commonGitDir, existing)
+			if current != head.SHA {
+				if !worktree.IsAncestor(current, head.SHA) && !force {
+					return fmt.Errorf("local branch %s has diverged from the pull request head; use --force to reset it", existing)
+				}
+				if err := worktree.MoveBranch(commonGitDir, existing, head.SHA, current); err != nil {
+					return err
+				}
+				entry := journal.New("pr", args)
+				entry.AddBranch(existing, current, head.SHA)
+				recordJournal(commonGitDir, entry)
+			}
+			spec = worktree.CreateSpec{Mode: worktree.ModeExisting, Name: existing, Branch: existing, Ref: existing}
+		}
+
+		_, err = runCreate(spec, opts)
+		return err
+	},
+}
+
+// refreshPullRequest moves an existing pull request worktree to the latest head.
+func refreshPullRequest(wt worktree.Worktree, head worktree.PullRequestHead, force bool, opts createOptions) error {
+	status := "up-to-date"
+	if wt.Head != head.SHA {
+		switch {
+		case worktree.IsAncestor(wt.Head, head.SHA):
+			if err := runGitInDir(wt.Path, "merge", "--ff-only", "--quiet", head.SHA); err != nil {
+				return err
+			}
+		case force:
+			// --keep refuses to discard uncommitted changes.
+			if err := runGitInDir(wt.Path, "reset", "--keep", head.SHA); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s cannot be fast-forwarded to %s (force-pushed or local commits); use --force to reset it", wt.Branch, shortSHA(head.SHA))
+		}
+		status = "updated"
+
+		commonGitDir, _ := worktree.GetCommonGitDir(wt.Path)
+		entry := journal.New("pr", []string{strconv.Itoa(head.Number)})
+		entry.AddBranch(wt.Branch, wt.Head, worktree.BranchSHA(commonGitDir, wt.Branch))
+		recordJournal(commonGitDir, entry)
+	}
+
+	out := os.Stdout
+	if opts.printPath {
+		out = os.Stderr
+	}
+	switch opts.format {
+	case outputFormatPretty:
+		if status == "updated" {
+			fmt.Fprintln(out, successStyle.Render("✓")+" Updated "+fileStyle.Render(wt.Branch)+" to "+shortSHA(head.SHA))
+		} else {
+			fmt.Fprintln(out, successStyle.Render("✓")+" "+fileStyle.Render(wt.Branch)+" is already at "+shortSHA(head.SHA))
+		}
+		fmt.Fprintln(out, fileStyle.Render(wt.Path))
+	case outputFormatPlain:
+		fmt.Fprintf(out, "status=%s\n", status)
+		fmt.Fprintf(out, "path=%s\n", wt.Path)
+	case outputFormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(createResult{Status: status, Branch: wt.Branch, From: head.SHA, Path: wt.Path, Mode: "refresh"}); err != nil {
+			return err
+		}
+	}
+	if opts.printPath {
+		fmt.Println(wt.Path)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+	prCmd.Flags().String("remote", "", "Remote to fetch the pull request from (defaults to origin)")
+	prCmd.Flags().String("slug", "", "Branch name suffix (defaults to a slug of the head commit subject)")
+	prCmd.Flags().Bool("force", false, "Reset an existing pull request branch that cannot be fast-forwarded")
+	addCreateFlags(prCmd)
+}
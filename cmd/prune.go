@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/registry"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+// pruneStale is the kind of a worktree git still registers although its
+// directory is gone.
+const pruneStale = "stale"
+
+// pruneResult is what gwt prune found. Size and Count cover the items it
+// deletes; items with Kept set are only reported.
+type pruneResult struct {
+	Root   string              `json:"root,omitempty"`
+	Items  []worktree.Leftover `json:"items"`
+	Count  int                 `json:"count"`
+	Size   int64               `json:"size"`
+	Pruned bool                `json:"pruned"`
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete stale worktree records and leftover directories",
+	Long: "Without --root, prune the current repository's records of worktrees whose\n" +
+		"directory is gone ('git worktree prune'); locked worktrees are kept.\n\n" +
+		"With --root, look under the gwt root (settings.root, or --path) for what git's\n" +
+		"prune never sees:\n" +
+		"  orphan   worktrees whose repository was deleted or moved away\n" +
+		"  empty    directories without any files, e.g. emptied project directories\n" +
+		"  partial  directories with files but no worktree, e.g. from a failed 'gwt new'\n" +
+		"Directories changed in the last minute are left alone. Orphans and partial\n" +
+		"directories are only deleted when the registry records them or they match\n" +
+		"settings.path_template, and worktrees whose .git file cannot be read never are;\n" +
+		"the others are listed as kept. If a repository was only moved, run\n" +
+		"'gwt doctor --fix=links' from it instead of pruning its worktrees.\n\n" +
+		"gwt prune lists what it would delete and asks for confirmation; --yes skips the\n" +
+		"question. Without a terminal and without --yes it only reports.",
+	Example: "  gwt prune\n" +
+		"  gwt prune --root\n" +
+		"  gwt prune --root --yes --json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootMode, _ := cmd.Flags().GetBool("root")
+		overridePath, _ := cmd.Flags().GetString("path")
+		yes, _ := cmd.Flags().GetBool("yes")
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		if overridePath != "" && !rootMode {
+			return fmt.Errorf("--path requires --root")
+		}
+
+		var result pruneResult
+		if rootMode {
+			root, err := worktree.ResolveRoot(overridePath)
+			if err != nil {
+				return err
+			}
+			pathTemplate := ""
+			if cfg, err := config.LoadConfig(); err == nil {
+				pathTemplate = cfg.Settings.PathTemplate
+			}
+			result.Root = root
+			result.Items = worktree.FindLeftovers(root, pathTemplate)
+		} else {
+			release, err := acquireRepoLock(cmd, args)
+			if err != nil {
+				return err
+			}
+			defer release()
+			if result.Items, err = staleWorktrees(); err != nil {
+				return err
+			}
+		}
+		for _, l := range result.Items {
+			if l.Kept == "" {
+				result.Count++
+				result.Size += l.Size
+			}
+		}
+
+		if format == outputFormatPretty && len(result.Items) > 0 {
+			printPruneItems(result)
+		}
+		asked := !yes && canConfirm(format)
+		if result.Count > 0 && (yes || asked && confirmPrune(result)) {
+			if err := prune(result); err != nil {
+				return err
+			}
+			result.Pruned = true
+		}
+		if err := printPrune(result, format); err != nil {
+			return err
+		}
+		if result.Count > 0 && !result.Pruned && !asked {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: nothing deleted; run with --yes to delete"))
+		}
+		return nil
+	},
+}
+
+// staleWorktrees returns the current repository's prunable, unlocked worktrees.
+func staleWorktrees() ([]worktree.Leftover, error) {
+	worktrees, err := worktree.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	items := []worktree.Leftover{}
+	for _, wt := range worktrees {
+		if wt.Prunable && !wt.Locked {
+			items = append(items, worktree.Leftover{Path: wt.Path, Kind: pruneStale, Detail: wt.PrunableReason})
+		}
+	}
+	return items, nil
+}
+
+// canConfirm reports whether gwt prune may ask before deleting: never for
+// --plain or --json output, or without a terminal.
+func canConfirm(format outputFormat) bool {
+	return format == outputFormatPretty && isTTY(os.Stdin) && isTTY(os.Stderr)
+}
+
+func confirmPrune(result pruneResult) bool {
+	fmt.Fprintf(os.Stderr, "Delete %d item(s), %s? [y/N] ", result.Count, formatSize(result.Size))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func prune(result pruneResult) error {
+	if result.Root == "" {
+		commonGitDir, err := worktree.CurrentCommonGitDir()
+		if err != nil {
+			return err
+		}
+		return worktree.Prune(commonGitDir)
+	}
+
+	var failed int
+	for _, l := range result.Items {
+		if l.Kept != "" {
+			continue
+		}
+		if err := worktree.RemoveLeftover(result.Root, l); err != nil {
+			fmt.Fprintln(os.Stderr, xMark+" "+err.Error())
+			failed++
+		}
+	}
+	// Loading drops the entries of deleted worktrees and frees their ports.
+	_ = registry.Update(result.Root, func(*registry.Registry) error { return nil })
+	if failed > 0 {
+		return fmt.Errorf("could not remove %d of %d item(s)", failed, result.Count)
+	}
+	return nil
+}
+
+func printPrune(result pruneResult, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	case outputFormatPlain:
+		for _, l := range result.Items {
+			fmt.Printf("%s\t%d\t%s\t%s\t%s\n", l.Kind, l.Size, l.Path, l.Detail, l.Kept)
+		}
+		return nil
+	}
+	switch {
+	case result.Count == 0:
+		fmt.Println(successStyle.Render("✓") + " Nothing to prune")
+	case result.Pruned:
+		fmt.Printf("%s Pruned %d item(s), %s\n", successStyle.Render("✓"), result.Count, formatSize(result.Size))
+	}
+	if kept := len(result.Items) - result.Count; kept > 0 {
+		fmt.Fprintf(os.Stderr, "%s\n", infoStyle.Render(fmt.Sprintf("Note: kept %d item(s) gwt did not create or cannot read", kept)))
+	}
+	return nil
+}
+
+// printPruneItems writes the items with their sizes and a total.
+func printPruneItems(result pruneResult) {
+	title := "Stale worktrees"
+	if result.Root != "" {
+		title = "Leftovers under " + result.Root
+	}
+	fmt.Println(titleStyle.Render(title))
+	for _, l := range result.Items {
+		line := fmt.Sprintf("  %9s  %-7s  %s", formatSize(l.Size), l.Kind, fileStyle.Render(l.Path))
+		if l.Detail != "" {
+			line += " " + infoStyle.Render("("+l.Detail+")")
+		}
+		if l.Kept != "" {
+			line += " " + lockedStyle.Render("kept: "+l.Kept)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("  %9s  total to delete\n", formatSize(result.Size))
+}
+
+// formatSize renders a byte count with binary units.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().Bool("root", false, "Prune leftover directories under the configured root instead of the current repository")
+	pruneCmd.Flags().String("path", "", "Override root path to scan (defaults to settings.root)")
+	pruneCmd.Flags().BoolP("yes", "y", false, "Delete without asking")
+	pruneCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	pruneCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
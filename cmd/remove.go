@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/journal"
 	"github.com/nachoal/gwt/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -16,51 +18,84 @@ var removeCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		branchName := args[0]
 		force, _ := cmd.Flags().GetBool("force")
-		if err := removeWorktreeByBranch(branchName, force); err != nil {
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		commonGitDir, _ := worktree.CurrentCommonGitDir()
+		entry := journal.New("remove", args)
+		err = removeWorktreeByBranch(branchName, force, force, entry)
+		recordJournal(commonGitDir, entry)
+		if err != nil {
 			return err
 		}
 
+		if len(entry.Branches) == 0 {
+			fmt.Println(successStyle.Render("✓") + " Removed worktree: " + fileStyle.Render(branchName))
+			return nil
+		}
 		fmt.Println(successStyle.Render("✓") + " Removed worktree and branch: " + fileStyle.Render(branchName))
 		return nil
 	},
 }
 
-func removeWorktreeByBranch(branchName string, force bool) error {
+// removeWorktreeByBranch removes the worktree for branchName and deletes the
+// branch, recording what was removed in entry. force discards uncommitted
+// changes; forceBranch deletes the branch even if it is not merged.
+func removeWorktreeByBranch(branchName string, force, forceBranch bool, entry *journal.Entry) error {
 	// Find the worktree path
 	worktrees, err := worktree.List()
 	if err != nil {
 		return err
 	}
 
-	var targetPath string
-	for _, wt := range worktrees {
-		if wt.Branch == branchName {
-			targetPath = wt.Path
-			break
-		}
+	wt, err := worktree.Find(worktrees, branchName)
+	if err != nil {
+		return err
 	}
-
-	if targetPath == "" {
-		return fmt.Errorf("worktree for branch '%s' not found", branchName)
+	if err := checkUnlocked(wt); err != nil {
+		return err
 	}
+	targetPath, targetHead := wt.Path, wt.Head
+	// A path or a detached worktree's name may have been given.
+	branchName = wt.Branch
 
 	// Determine common git dir before removal (branch is checked out here)
 	commonGitDir, _ := worktree.GetCommonGitDir(targetPath)
 
 	// Remove the worktree first to unlock the branch
-	if err := worktree.Remove(targetPath, force); err != nil {
+	if err := worktree.Remove(registryRoot(), targetPath, force); err != nil {
 		return err
 	}
+	entry.AddWorktree(journal.ActionRemoved, targetPath, branchName, targetHead)
+	if branchName == "" {
+		return nil
+	}
 
-	// Also delete the branch (safe delete unless --force)
-	if err := worktree.DeleteBranchWithGitDir(commonGitDir, branchName, force); err != nil {
+	// Also delete the branch (safe delete unless forced)
+	branchBefore := worktree.BranchSHA(commonGitDir, branchName)
+	if err := worktree.DeleteBranchWithGitDir(commonGitDir, branchName, forceBranch); err != nil {
 		// Warn but don't fail the command if branch deletion fails (e.g., unmerged)
 		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not delete branch ")+fileStyle.Render(branchName))
 	}
+	entry.AddBranch(branchName, branchBefore, worktree.BranchSHA(commonGitDir, branchName))
 
 	return nil
 }
 
+// registryRoot is the root whose registry records worktrees, or "" when the
+// config cannot be loaded; registry bookkeeping is best effort.
+func registryRoot() string {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return ""
+	}
+	return cfg.Settings.Root
+}
+
 func init() {
 	rootCmd.AddCommand(removeCmd)
 	removeCmd.Flags().BoolP("force", "f", false, "Force removal even if there are uncommitted changes")
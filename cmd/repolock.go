@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nachoal/gwt/internal/lock"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var lockTimeout time.Duration
+
+// acquireRepoLock takes the per-repository lock that serializes mutating gwt
+// commands (worktree creation, file copies, config migration, removal).
+// Read-only commands do not need it. Call the returned func to release.
+func acquireRepoLock(cmd *cobra.Command, args []string) (func(), error) {
+	commonGitDir, err := worktree.CurrentCommonGitDir()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	description := strings.TrimSpace("gwt " + cmd.Name() + " " + strings.Join(args, " "))
+	l, err := lock.Acquire(lock.RepoPath(commonGitDir), description, lockTimeout, func(h *lock.Holder) {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Waiting for repository lock held by "+h.String()+"..."))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = l.Release() }, nil
+}
+
+func defaultLockTimeout() time.Duration {
+	if v := strings.TrimSpace(os.Getenv("GWT_LOCK_TIMEOUT")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return lock.DefaultTimeout
+}
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", defaultLockTimeout(), "How long mutating commands wait for another gwt process in the same repository (env: GWT_LOCK_TIMEOUT)")
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <branch> [--] <command> [args...]",
+	Short: "Run a command in a worktree without changing directory",
+	Long: "Run a command inside the worktree for branch, from anywhere in the repository.\n\n" +
+		"stdin, stdout and stderr are passed through, signals sent to gwt are forwarded\n" +
+		"to the command, and gwt exits with the command's exit status. The command sees\n" +
+		"GWT_WORKTREE, GWT_BRANCH, GWT_PROJECT and (when recorded) GWT_BASE.",
+	Example: "  gwt run feature/foo -- npm test\n" +
+		"  gwt run feature/foo git log --oneline -5",
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branch, argv := args[0], args[1:]
+		if argv[0] == "--" {
+			argv = argv[1:]
+		}
+		if len(argv) == 0 {
+			return fmt.Errorf("missing command to run")
+		}
+
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		wt, err := statusTarget(worktrees, []string{branch})
+		if err != nil {
+			return err
+		}
+
+		project := ""
+		if cfg, err := config.LoadConfig(); err == nil {
+			project, _ = worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+		}
+
+		c := exec.Command(argv[0], argv[1:]...)
+		c.Dir = wt.Path
+		c.Env = append(os.Environ(), worktreeEnv(project, wt.Branch, wt.Path)...)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+		if err := c.Start(); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				// Match the shell's status for a missing command.
+				fmt.Fprintln(os.Stderr, "gwt run: "+err.Error())
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+				return &ExitCodeError{Code: 127}
+			}
+			return err
+		}
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+		defer signal.Stop(sigs)
+		go func() {
+			for sig := range sigs {
+				// Ctrl-C at a terminal already reaches the command through the
+				// foreground process group; only relay SIGINT sent to gwt itself.
+				if sig == os.Interrupt && isTTY(os.Stdin) {
+					continue
+				}
+				_ = c.Process.Signal(sig)
+			}
+		}()
+
+		err = c.Wait()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// The command reports its own errors; just pass its status on.
+			cmd.SilenceErrors, cmd.SilenceUsage = true, true
+			return &ExitCodeError{Code: exitStatus(exitErr)}
+		}
+		return err
+	},
+}
+
+// exitStatus maps a finished process to a shell-style exit status:
+// 128+N for a process killed by signal N.
+func exitStatus(exitErr *exec.ExitError) int {
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// ExitCodeError makes gwt exit with Code without printing an error.
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	var exitErr *ExitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	// Everything after the branch belongs to the command: gwt run feat npm test --watch
+	runCmd.Flags().SetInterspersed(false)
+}
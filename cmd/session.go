@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/session"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+func addSessionFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("tmux", false, "Open (or attach to) a tmux session for the worktree")
+	cmd.Flags().Bool("zellij", false, "Open (or switch to) a zellij tab, or a session outside zellij, for the worktree")
+}
+
+// sessionFromFlags returns the multiplexer selected by --tmux/--zellij, or "".
+func sessionFromFlags(cmd *cobra.Command) (string, error) {
+	tmux, _ := cmd.Flags().GetBool("tmux")
+	zellij, _ := cmd.Flags().GetBool("zellij")
+	switch {
+	case tmux && zellij:
+		return "", fmt.Errorf("--tmux and --zellij are mutually exclusive")
+	case tmux:
+		return session.Tmux, nil
+	case zellij:
+		return session.Zellij, nil
+	}
+	return "", nil
+}
+
+// openSession opens the worktree's session, named after the project and
+// branch and laid out by the session: config, and records it in the
+// worktree's metadata so that removing the worktree ends it.
+func openSession(multiplexer, path, branch string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	project, err := worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+	if err != nil {
+		return err
+	}
+	if branch == "" {
+		branch = filepath.Base(path)
+	}
+	s, err := session.New(multiplexer, worktree.SessionName(project, branch))
+	if err != nil {
+		return err
+	}
+	if err := worktree.UpdateMetadata(path, func(m *worktree.Metadata) { m.Session = &s }); err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not record session in worktree metadata: "+err.Error()))
+	}
+
+	var windows []session.Window
+	if cfg.Session != nil {
+		for _, w := range cfg.Session.Windows {
+			windows = append(windows, session.Window{Name: w.Name, Command: w.Command})
+		}
+	}
+	return s.Open(path, windows)
+}
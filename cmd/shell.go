@@ -55,43 +55,40 @@ function gwt {
 
     new)
       shift
-      # Parse args; support: -f/--from, -v, -t and shell-only -c/--claude
+      # Parse args; -c/--claude is shorthand for the default agent
+      # (settings.agent): -c, -c "prompt", -c issue <url>.
       local branch=""
-      local claude=0
-      local claude_prompt=""
       local pass=()
 
       while [ $# -gt 0 ]; do
         case "$1" in
           -c|--claude)
-            claude=1
+            pass+=("--agent" "default")
             shift
             if [ $# -gt 0 ]; then
               if [ "$1" = "issue" ]; then
                 shift
                 if [ $# -gt 0 ]; then
-                  claude_prompt="/issue-analysis $1"
+                  pass+=("--issue" "$1")
                   shift
                 else
                   echo "gwt: -c issue requires a <link>" >&2
                   return 1
                 fi
               else
-                claude_prompt="$1"
+                pass+=("--prompt" "$1")
                 shift
               fi
             fi
             ;;
-          -f|--from)
+          -f|--from|-a|--agent|-p|--prompt|--note|--issue)
             pass+=("$1")
-            shift
-            if [ $# -gt 0 ]; then
-              pass+=("$1")
-              shift
-            else
-              echo "gwt: -f/--from requires a value" >&2
+            if [ $# -lt 2 ]; then
+              echo "gwt: $1 requires a value" >&2
               return 1
             fi
+            pass+=("$2")
+            shift 2
             ;;
           -*)
             pass+=("$1")
@@ -112,28 +109,38 @@ function gwt {
         return 1
       fi
 
-      command gwt new "${pass[@]}"
+      # The branch argument may be a remote branch or a ref (--detach), so ask
+      # gwt for the created path instead of looking it up by name. An agent
+      # started by --agent runs in the worktree before gwt returns.
+      local wt_path
+      wt_path=$(GWT_FORCE_TUI=1 command gwt new --print-path "${pass[@]}")
       local _gwt_ec=$?
+
+      if [ -n "$wt_path" ]; then
+        cd "$wt_path" || return $?
+        # Emit OSC 7 to inform WezTerm of directory change
+        printf "\033]7;file://%s%s\033\\" "${HOST:-$HOSTNAME}" "$PWD"
+      fi
       if [ $_gwt_ec -ne 0 ]; then
         return $_gwt_ec
       fi
+      if [ -z "$wt_path" ]; then
+        return 1
+      fi
+      ;;
 
+    pr)
+      shift
       local wt_path
-      wt_path=$(command gwt switch "$branch")
-      if [ $? -eq 0 ] && [ -n "$wt_path" ]; then
+      wt_path=$(GWT_FORCE_TUI=1 command gwt pr --print-path "$@")
+      local _gwt_ec=$?
+      if [ $_gwt_ec -ne 0 ]; then
+        return $_gwt_ec
+      fi
+      if [ -n "$wt_path" ]; then
         cd "$wt_path" || return $?
         # Emit OSC 7 to inform WezTerm of directory change
         printf "\033]7;file://%s%s\033\\" "${HOST:-$HOSTNAME}" "$PWD"
-      else
-        return 1
-      fi
-
-      if [ $claude -eq 1 ]; then
-        if [ -n "$claude_prompt" ]; then
-          claude "$claude_prompt"
-        else
-          claude
-        fi
       fi
       ;;
 
@@ -273,7 +280,7 @@ func detectDefaultRC() string {
 var shellCmd = &cobra.Command{
 	Use:   "shell",
 	Short: "Output or install shell integration",
-	Long:  "Output shell integration to enable 'gwt switch' to cd, plus helpers for 'new -c' and 'done'. Use --install to write a small source line into your shell rc (zsh/bash).",
+	Long:  "Output shell integration to enable 'gwt switch' to cd, plus helpers for 'new -c', 'pr' and 'done'. Use --install to write a small source line into your shell rc (zsh/bash).",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		install, _ := cmd.Flags().GetBool("install")
 		remove, _ := cmd.Flags().GetBool("remove")
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nachoal/gwt/internal/agent"
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/journal"
+	"github.com/nachoal/gwt/internal/spawn"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+// validateSpawnTask checks that task makes valid <task>/<n> branches and is
+// not shadowed by a subcommand of spawn, so 'gwt spawn status <task>' works.
+func validateSpawnTask(parent *cobra.Command, task string) error {
+	for _, c := range parent.Commands() {
+		if c.Name() == task || c.HasAlias(task) {
+			return fmt.Errorf("'%s' cannot be a task name: it is a 'gwt spawn' subcommand", task)
+		}
+	}
+	return worktree.ValidateBranchName(task + "/1")
+}
+
+var spawnCmd = &cobra.Command{
+	Use:   "spawn <task>",
+	Short: "Run several agents on the same task in parallel worktrees",
+	Long: "Create worktrees <task>/1 .. <task>/N from the same base, provision them (copy,\n" +
+		"ports, templates, compose, setup) concurrently, and start the agent in each one\n" +
+		"in the background with its output logged to a file.\n\n" +
+		"The agent runs its batch command (agents.<name>.batch, e.g. \"claude -p {{.Prompt}}\")\n" +
+		"since there is no terminal. The group is recorded so that 'gwt spawn status <task>'\n" +
+		"can compare the results; each worktree is also tagged with the task.",
+	Example: "  gwt spawn parser-fix -n 3 --agent claude --prompt \"fix the flaky parser test\"\n" +
+		"  gwt spawn status parser-fix",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		task := args[0]
+		n, _ := cmd.Flags().GetInt("count")
+		agentName, _ := cmd.Flags().GetString("agent")
+		prompt, _ := cmd.Flags().GetString("prompt")
+		issue, _ := cmd.Flags().GetString("issue")
+		from, _ := cmd.Flags().GetString("from")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		if n < 1 {
+			return fmt.Errorf("--count must be at least 1")
+		}
+		if jobs < 1 {
+			jobs = n
+		}
+		if prompt == "" && issue == "" {
+			return fmt.Errorf("spawned agents run unattended; give them a --prompt or --issue")
+		}
+		if err := validateSpawnTask(cmd, task); err != nil {
+			return err
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		agentName, agentCfg, err := agent.Resolve(cfg.Agents, cfg.Settings.Agent, agentName)
+		if err != nil {
+			return err
+		}
+		commonGitDir, err := worktree.CurrentCommonGitDir()
+		if err != nil {
+			return err
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		// Checked under the lock so that two runs cannot both claim the task.
+		if _, err := os.Stat(spawn.Path(commonGitDir, task)); err == nil {
+			return fmt.Errorf("spawn group '%s' already exists (see 'gwt spawn status %s')", task, task)
+		}
+
+		if !cmd.Flags().Changed("from") {
+			if from, err = worktree.GetDefaultBranch(); err != nil {
+				return err
+			}
+		}
+		out, err := exec.Command("git", "rev-parse", "--verify", "--quiet", from+"^{commit}").Output()
+		if err != nil {
+			return fmt.Errorf("unknown base '%s'", from)
+		}
+		group := &spawn.Group{
+			Task:      task,
+			Base:      from,
+			BaseSHA:   strings.TrimSpace(string(out)),
+			Agent:     agentName,
+			Prompt:    prompt,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		// Worktrees are created one at a time: git serializes them anyway.
+		specs, err := createSpawnWorktrees(cfg, group, n, commonGitDir, args)
+		if len(group.Members) > 0 {
+			if serr := spawn.Save(commonGitDir, group); serr != nil && err == nil {
+				err = serr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		// Provisioning only touches the new worktrees; let other gwt commands in.
+		release()
+
+		if format == outputFormatPretty {
+			fmt.Fprintln(os.Stderr, titleStyle.Render(fmt.Sprintf("Provisioning %d worktree(s) for %s", n, task)))
+		}
+		project, _ := worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+		mainPath, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		var wg sync.WaitGroup
+		var outMu sync.Mutex
+		queue := make(chan int)
+		for w := 0; w < jobs; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					m := &group.Members[i]
+					err := provisionSpawnMember(cfg, specs[i], project, mainPath, m)
+					if err == nil {
+						err = startSpawnAgent(agentCfg, group, project, issue, m)
+					}
+					if err != nil {
+						m.Error = err.Error()
+					}
+					if format == outputFormatPretty {
+						outMu.Lock()
+						if err != nil {
+							fmt.Fprintf(os.Stderr, " %s %s %s\n", xMark, m.Branch, infoStyle.Render(err.Error()))
+						} else {
+							fmt.Fprintf(os.Stderr, " %s %s %s\n", checkMark, m.Branch, infoStyle.Render(fmt.Sprintf("agent started (pid %d)", m.PID)))
+						}
+						outMu.Unlock()
+					}
+				}
+			}()
+		}
+		for i := range group.Members {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+
+		if err := spawn.Save(commonGitDir, group); err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, m := range group.Members {
+			if m.Error != "" {
+				failed++
+			}
+		}
+		switch format {
+		case outputFormatJSON:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(group); err != nil {
+				return err
+			}
+		case outputFormatPlain:
+			for _, m := range group.Members {
+				fmt.Printf("%s\t%s\t%d\t%s\t%s\n", m.Branch, m.Path, m.PID, m.Log, m.Error)
+			}
+		default:
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Logs: "+spawn.LogDir(commonGitDir, task)))
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Follow progress with: gwt spawn status "+task))
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d worktree(s) could not be started", failed, len(group.Members))
+		}
+		return nil
+	},
+}
+
+// createSpawnWorktrees creates the group's worktrees and adds them to
+// group.Members, journaling whatever was created. The caller holds the lock.
+func createSpawnWorktrees(cfg *config.Config, group *spawn.Group, n int, commonGitDir string, args []string) ([]worktree.CreateSpec, error) {
+	projectName, err := worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+	if err != nil {
+		return nil, err
+	}
+	entry := journal.New("spawn", args)
+	defer recordJournal(commonGitDir, entry)
+
+	var specs []worktree.CreateSpec
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("%s/%d", group.Task, i)
+		spec, err := worktree.ResolveCreateSpec(name, group.Base, false, false)
+		if err != nil {
+			return specs, err
+		}
+		if spec.Mode != worktree.ModeNew {
+			return specs, fmt.Errorf("branch '%s' already exists", name)
+		}
+		targetPath, err := worktree.RenderWorktreePath(cfg.Settings.PathTemplate, cfg.Settings.Root, projectName, name)
+		if err != nil {
+			return specs, err
+		}
+		// Branch from the recorded commit so every member starts identical,
+		// while spec.Ref keeps the base branch name for the metadata.
+		createSpec := spec
+		createSpec.Ref = group.BaseSHA
+		if err := worktree.CreateAt(createSpec, targetPath); err != nil {
+			return specs, err
+		}
+		entry.AddBranch(spec.Branch, "", worktree.BranchSHA(commonGitDir, spec.Branch))
+		entry.AddWorktree(journal.ActionAdded, targetPath, spec.Branch, worktree.HeadSHA(targetPath))
+		recordCreateMetadata(spec, targetPath)
+		if worktree.ValidateTag(group.Task) == nil {
+			_ = worktree.UpdateMetadata(targetPath, func(m *worktree.Metadata) { m.AddTags(group.Task) })
+		}
+		if err := worktree.Register(cfg.Settings.Root, projectName, name, targetPath); err != nil {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not record worktree in registry: "+err.Error()))
+		}
+
+		logDir := spawn.LogDir(commonGitDir, group.Task)
+		group.Members = append(group.Members, spawn.Member{
+			Index:    i,
+			Branch:   spec.Branch,
+			Path:     targetPath,
+			Log:      filepath.Join(logDir, fmt.Sprintf("%d.log", i)),
+			ExitFile: filepath.Join(logDir, fmt.Sprintf("%d.exit", i)),
+		})
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// provisionSpawnMember runs the create steps after `git worktree add` for a
+// spawned worktree, writing setup output to its log.
+func provisionSpawnMember(cfg *config.Config, spec worktree.CreateSpec, project, mainPath string, m *spawn.Member) error {
+	if err := os.MkdirAll(filepath.Dir(m.Log), 0o755); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(m.Log, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	if err := worktree.CopyFiles(mainPath, m.Path, cfg.Copy); err != nil {
+		return err
+	}
+	ports, err := worktree.AssignPorts(cfg.Settings.Root, m.Path, cfg.Ports)
+	if err != nil {
+		return err
+	}
+	data := worktree.NewTemplateData(spec, project, m.Path, mainPath, ports)
+	if err := worktree.RenderTemplates(mainPath, m.Path, cfg.Templates, data); err != nil {
+		return err
+	}
+	if _, err := worktree.StartCompose(cfg.Compose, project, spec.Name, m.Path, ports); err != nil {
+		return err
+	}
+	if len(cfg.Setup) > 0 {
+		fmt.Fprintln(logFile, "== gwt: setup ==")
+		if err := worktree.RunSetupCommandsOpts(m.Path, cfg.Setup, true, true, logFile); err != nil {
+			return fmt.Errorf("setup failed (see %s): %w", m.Log, err)
+		}
+	}
+	return nil
+}
+
+// startSpawnAgent starts the agent's batch command detached in m's worktree.
+func startSpawnAgent(a config.AgentConfig, group *spawn.Group, project, issue string, m *spawn.Member) error {
+	c, err := agent.Command(agent.Batch(a), agent.Data{
+		Prompt:  group.Prompt,
+		Issue:   issue,
+		Branch:  m.Branch,
+		Path:    m.Path,
+		Project: project,
+	})
+	if err != nil {
+		return err
+	}
+	if f, err := os.OpenFile(m.Log, os.O_WRONLY|os.O_APPEND, 0o644); err == nil {
+		fmt.Fprintf(f, "== gwt: %s ==\n", strings.Join(c.Args, " "))
+		f.Close()
+	}
+	env := append(worktreeEnv(project, m.Branch, m.Path),
+		"GWT_SPAWN_TASK="+group.Task,
+		fmt.Sprintf("GWT_SPAWN_INDEX=%d", m.Index))
+	return m.Start(c.Args, c.Dir, env)
+}
+
+func init() {
+	rootCmd.AddCommand(spawnCmd)
+	spawnCmd.Flags().IntP("count", "n", 2, "Number of worktrees and agents")
+	addAgentFlags(spawnCmd)
+	spawnCmd.Flags().String("issue", "", "Issue URL; the prompt comes from the agent's issue_prompt template")
+	spawnCmd.Flags().StringP("from", "f", "", "Base branch, tag or commit (defaults to the default branch)")
+	spawnCmd.Flags().IntP("jobs", "j", 0, "Number of worktrees to provision concurrently (default: all)")
+	spawnCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	spawnCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nachoal/gwt/internal/spawn"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+type spawnMemberStatus struct {
+	spawn.Member
+	State    string      `json:"state"`
+	ExitCode int         `json:"exit_code"`
+	Elapsed  string      `json:"elapsed,omitempty"`
+	Diff     *spawn.Diff `json:"diff,omitempty"`
+}
+
+type spawnGroupStatus struct {
+	Task      string              `json:"task"`
+	Base      string              `json:"base"`
+	BaseSHA   string              `json:"base_sha"`
+	Agent     string              `json:"agent"`
+	Prompt    string              `json:"prompt,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	Members   []spawnMemberStatus `json:"members"`
+}
+
+var spawnStatusCmd = &cobra.Command{
+	Use:   "status [task]",
+	Short: "Show the progress and diffs of a spawn group",
+	Long: "Without a task, list the recorded spawn groups. With a task, show each member's\n" +
+		"agent state and what it changed since the common base (committed, uncommitted\n" +
+		"and untracked), followed by a file-by-member matrix to compare the attempts.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		commonGitDir, err := worktree.CurrentCommonGitDir()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			groups, err := spawn.List(commonGitDir)
+			if err != nil {
+				return err
+			}
+			return printSpawnGroups(groups, format)
+		}
+
+		g, err := spawn.Load(commonGitDir, args[0])
+		if err != nil {
+			return err
+		}
+		st := spawnGroupStatus{Task: g.Task, Base: g.Base, BaseSHA: g.BaseSHA, Agent: g.Agent, Prompt: g.Prompt, CreatedAt: g.CreatedAt}
+		for _, m := range g.Members {
+			ms := spawnMemberStatus{Member: m}
+			ms.State, ms.ExitCode = m.State()
+			if !m.StartedAt.IsZero() && ms.State == spawn.StateRunning {
+				ms.Elapsed = time.Since(m.StartedAt).Round(time.Second).String()
+			}
+			if _, err := os.Stat(m.Path); err == nil {
+				if d, err := m.Diff(g.BaseSHA); err == nil {
+					ms.Diff = &d
+				}
+			}
+			st.Members = append(st.Members, ms)
+		}
+		return printSpawnStatus(st, format)
+	},
+}
+
+func printSpawnGroups(groups []*spawn.Group, format outputFormat) error {
+	if format == outputFormatJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if groups == nil {
+			groups = []*spawn.Group{}
+		}
+		return enc.Encode(groups)
+	}
+	if len(groups) == 0 && format == outputFormatPretty {
+		fmt.Println(infoStyle.Render("No spawn groups. Start one with: gwt spawn <task> -n 3 --prompt \"...\""))
+		return nil
+	}
+	for _, g := range groups {
+		counts := map[string]int{}
+		for _, m := range g.Members {
+			state, _ := m.State()
+			counts[state]++
+		}
+		var parts []string
+		for _, state := range []string{spawn.StateRunning, spawn.StateDone, spawn.StateFailed, spawn.StateLost, spawn.StatePending} {
+			if counts[state] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", counts[state], state))
+			}
+		}
+		if format == outputFormatPlain {
+			fmt.Printf("%s\t%s\t%d\t%s\n", g.Task, g.Agent, len(g.Members), strings.Join(parts, ","))
+			continue
+		}
+		fmt.Printf("%s %s %s\n", titleStyle.Render(g.Task), infoStyle.Render(g.Agent+", "+g.CreatedAt.Local().Format("2006-01-02 15:04")+":"), strings.Join(parts, ", "))
+	}
+	return nil
+}
+
+func printSpawnStatus(st spawnGroupStatus, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	case outputFormatPlain:
+		fmt.Println("branch\tstate\texit\tfiles\tinsertions\tdeletions\tuntracked\tcommits\tpath")
+		for _, m := range st.Members {
+			d := m.Diff
+			if d == nil {
+				d = &spawn.Diff{}
+			}
+			fmt.Printf("%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n", m.Branch, m.State, m.ExitCode, len(d.Files), d.Insertions, d.Deletions, d.Untracked, d.Commits, m.Path)
+		}
+		return nil
+	}
+
+	fmt.Println(titleStyle.Render(st.Task) + infoStyle.Render(fmt.Sprintf(" %s from %s (%s)", st.Agent, st.Base, shortSHA(st.BaseSHA))))
+	if st.Prompt != "" {
+		fmt.Println(infoStyle.Render("prompt: " + st.Prompt))
+	}
+	fmt.Println()
+	for _, m := range st.Members {
+		mark := infoStyle.Render("…")
+		switch m.State {
+		case spawn.StateDone:
+			mark = checkMark
+		case spawn.StateFailed, spawn.StateLost:
+			mark = xMark
+		}
+		state := m.State
+		switch {
+		case m.Error != "":
+			state += ": " + m.Error
+		case m.State == spawn.StateFailed:
+			state += fmt.Sprintf(" (exit %d)", m.ExitCode)
+		case m.Elapsed != "":
+			state += " " + m.Elapsed
+		}
+		changes := "worktree removed"
+		if d := m.Diff; d != nil {
+			changes = fmt.Sprintf("%d file(s) +%d -%d", len(d.Files), d.Insertions, d.Deletions)
+			if d.Commits > 0 {
+				changes += fmt.Sprintf(", %d commit(s)", d.Commits)
+			}
+		}
+		fmt.Printf(" %s %-24s %-28s %s\n", mark, m.Branch, state, infoStyle.Render(changes))
+	}
+
+	// Files changed by any member, with a column per member.
+	files := map[string]map[int]bool{}
+	var order []string
+	for _, m := range st.Members {
+		if m.Diff == nil {
+			continue
+		}
+		for _, f := range m.Diff.Files {
+			if files[f] == nil {
+				files[f] = map[int]bool{}
+				order = append(order, f)
+			}
+			files[f][m.Index] = true
+		}
+	}
+	if len(order) == 0 {
+		return nil
+	}
+	sort.Strings(order)
+	fmt.Println()
+	header := "   "
+	for _, m := range st.Members {
+		header += fmt.Sprintf("%3d", m.Index)
+	}
+	fmt.Println(infoStyle.Render(header))
+	for _, f := range order {
+		row := "   "
+		for _, m := range st.Members {
+			if files[f][m.Index] {
+				row += "  ●"
+			} else {
+				row += "  " + infoStyle.Render("·")
+			}
+		}
+		fmt.Printf("%s  %s\n", row, fileStyle.Render(f))
+	}
+	return nil
+}
+
+func init() {
+	spawnCmd.AddCommand(spawnStatusCmd)
+	spawnStatusCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	spawnStatusCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSpawnTask(t *testing.T) {
+	tests := []struct {
+		task    string
+		wantErr string
+	}{
+		{task: "parser-fix"},
+		{task: "issue/42"},
+		{task: "status", wantErr: "'status' cannot be a task name"},
+		{task: "a..b", wantErr: "invalid branch name"},
+		{task: "-x", wantErr: "invalid branch name"},
+	}
+	for _, tt := range tests {
+		err := validateSpawnTask(spawnCmd, tt.task)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("validateSpawnTask(%q): unexpected error %v", tt.task, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("validateSpawnTask(%q) error = %v, want %q", tt.task, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/forge"
+	"github.com/nachoal/gwt/internal/lease"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+type statusResult struct {
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+	Head   string `json:"head"`
+	worktree.WorktreeStatus
+	PR       *forge.PullRequest `json:"pr,omitempty"`
+	Metadata *worktree.Metadata `json:"metadata,omitempty"`
+	Compose  *composeStatus     `json:"compose,omitempty"`
+	Lease    *lease.Lease       `json:"lease,omitempty"`
+}
+
+// composeStatus reports whether a worktree's compose stack is up.
+type composeStatus struct {
+	Project string `json:"project"`
+	// Running is the number of running containers.
+	Running int    `json:"running"`
+	Error   string `json:"error,omitempty"`
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status [branch]",
+	Short: "Show the state of a worktree (changes, upstream, pull request)",
+	Long: "Show the state of the worktree for branch, or of the current worktree.\n" +
+		"With --all, show every worktree of the repository.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		if all && len(args) > 0 {
+			return fmt.Errorf("--all cannot be combined with a branch")
+		}
+
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		targets := worktrees
+		if !all {
+			wt, err := statusTarget(worktrees, args)
+			if err != nil {
+				return err
+			}
+			targets = []worktree.Worktree{wt}
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		provider := forgeProvider(cfg)
+
+		results := make([]statusResult, 0, len(targets))
+		for _, wt := range targets {
+			if wt.Prunable && all {
+				fmt.Fprintln(os.Stderr, infoStyle.Render("Note: skipping stale worktree "+wt.Path+" ("+wt.PrunableReason+")"))
+				continue
+			}
+			st, err := worktree.Status(wt.Path)
+			if err != nil {
+				return err
+			}
+			meta, err := worktree.ReadMetadata(wt.Path)
+			if err != nil {
+				return err
+			}
+			results = append(results, statusResult{
+				Branch:         wt.Branch,
+				Path:           wt.Path,
+				Head:           wt.Head,
+				WorktreeStatus: st,
+				PR:             lookupPullRequest(provider, wt.Branch),
+				Metadata:       meta,
+				Compose:        stackStatus(wt.Path),
+				Lease:          worktree.ActiveLease(wt.Path),
+			})
+		}
+
+		if format == outputFormatJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if all {
+				return enc.Encode(results)
+			}
+			return enc.Encode(results[0])
+		}
+
+		for i, r := range results {
+			if format == outputFormatPretty {
+				if i > 0 {
+					fmt.Println()
+				}
+				title := r.Branch
+				if title == "" {
+					title = filepath.Base(r.Path) + " (detached)"
+				}
+				fmt.Println(titleStyle.Render(title))
+				fmt.Printf("  %s %s\n", infoStyle.Render("path:    "), fileStyle.Render(r.Path))
+				fmt.Printf("  %s %s\n", infoStyle.Render("head:    "), shortSHA(r.Head))
+				fmt.Printf("  %s %s\n", infoStyle.Render("changes: "), describeChanges(r.Changes))
+				if r.Upstream != "" {
+					fmt.Printf("  %s %s (ahead %d, behind %d)\n", infoStyle.Render("upstream:"), r.Upstream, r.Ahead, r.Behind)
+				}
+				if r.PR != nil {
+					fmt.Printf("  %s %s %s\n", infoStyle.Render("pr:      "), r.PR.Label(), infoStyle.Render(r.PR.URL))
+				}
+				if m := r.Metadata; m != nil {
+					if m.Base != "" {
+						fmt.Printf("  %s %s\n", infoStyle.Render("base:    "), m.Base)
+					}
+					if !m.CreatedAt.IsZero() {
+						fmt.Printf("  %s %s\n", infoStyle.Render("created: "), describeCreation(m))
+					}
+					if m.Description != "" {
+						fmt.Printf("  %s %s\n", infoStyle.Render("note:    "), m.Description)
+					}
+					if m.IssueURL != "" {
+						fmt.Printf("  %s %s\n", infoStyle.Render("issue:   "), m.IssueURL)
+					}
+					if len(m.Tags) > 0 {
+						fmt.Printf("  %s %s\n", infoStyle.Render("tags:    "), strings.Join(m.Tags, ", "))
+					}
+				}
+				if c := r.Compose; c != nil {
+					fmt.Printf("  %s %s %s\n", infoStyle.Render("compose: "), c.Project, infoStyle.Render("("+describeStack(c)+")"))
+				}
+				if l := r.Lease; l != nil {
+					fmt.Printf("  %s %s\n", infoStyle.Render("lease:   "), describeLease(l))
+				}
+			}
+			if format == outputFormatPlain {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("branch=%s\n", r.Branch)
+				fmt.Printf("path=%s\n", r.Path)
+				fmt.Printf("head=%s\n", r.Head)
+				fmt.Printf("changes=%d\n", r.Changes)
+				fmt.Printf("upstream=%s\n", r.Upstream)
+				fmt.Printf("ahead=%d\n", r.Ahead)
+				fmt.Printf("behind=%d\n", r.Behind)
+				if r.PR != nil {
+					fmt.Printf("pr=%d\n", r.PR.Number)
+					fmt.Printf("pr_state=%s\n", r.PR.State)
+				}
+				if m := r.Metadata; m != nil {
+					fmt.Printf("base=%s\n", m.Base)
+					createdAt := ""
+					if !m.CreatedAt.IsZero() {
+						createdAt = m.CreatedAt.Format(time.RFC3339)
+					}
+					fmt.Printf("created_at=%s\n", createdAt)
+					fmt.Printf("creator=%s\n", m.Creator)
+					fmt.Printf("gwt_version=%s\n", m.GwtVersion)
+					fmt.Printf("description=%s\n", m.Description)
+					fmt.Printf("issue_url=%s\n", m.IssueURL)
+					fmt.Printf("tags=%s\n", strings.Join(m.Tags, ","))
+				}
+				if c := r.Compose; c != nil {
+					fmt.Printf("compose_project=%s\n", c.Project)
+					fmt.Printf("compose_running=%d\n", c.Running)
+				}
+				if l := r.Lease; l != nil {
+					fmt.Printf("lease_owner=%s\n", l.Owner)
+					fmt.Printf("lease_expires_at=%s\n", l.ExpiresAt.Format(time.RFC3339))
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// statusTarget picks the worktree args names (a branch, a path or the
+// directory name of a detached worktree), or the one containing the current
+// directory.
+func statusTarget(worktrees []worktree.Worktree, args []string) (worktree.Worktree, error) {
+	if len(args) == 1 {
+		return worktree.Find(worktrees, args[0])
+	}
+
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return worktree.Worktree{}, fmt.Errorf("not inside a worktree; pass a branch or --all")
+	}
+	top := strings.TrimSpace(string(out))
+	for _, wt := range worktrees {
+		if worktree.SamePath(wt.Path, top) {
+			return wt, nil
+		}
+	}
+	return worktree.Worktree{}, fmt.Errorf("current directory is not a worktree of this repository")
+}
+
+func describeCreation(m *worktree.Metadata) string {
+	s := m.CreatedAt.Local().Format("2006-01-02 15:04")
+	if m.Creator != "" {
+		s += " by " + m.Creator
+	}
+	if m.GwtVersion != "" {
+		s += " (gwt " + m.GwtVersion + ")"
+	}
+	return s
+}
+
+// stackStatus queries the compose stack recorded for the worktree at path, if any.
+func stackStatus(path string) *composeStatus {
+	stack := worktree.ComposeStack(path)
+	if stack == nil {
+		return nil
+	}
+	st := &composeStatus{Project: stack.Project}
+	n, err := stack.Running(worktree.ComposeEnv(path))
+	if err != nil {
+		st.Error = err.Error()
+	}
+	st.Running = n
+	return st
+}
+
+func describeStack(c *composeStatus) string {
+	switch {
+	case c.Error != "":
+		return "unknown: " + c.Error
+	case c.Running == 0:
+		return "stopped"
+	}
+	return fmt.Sprintf("running, %d container(s)", c.Running)
+}
+
+func describeChanges(n int) string {
+	if n == 0 {
+		return "clean"
+	}
+	return fmt.Sprintf("%d changed path(s)", n)
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().Bool("all", false, "Show every worktree of the repository")
+	statusCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	statusCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+
 	"github.com/nachoal/gwt/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -10,9 +11,17 @@ var switchCmd = &cobra.Command{
 	Use:     "switch <branch-name>",
 	Aliases: []string{"sw"},
 	Short:   "Switch to a worktree (requires shell integration)",
-	Args:    cobra.ExactArgs(1),
+	Long: "Switch to the worktree for a branch. The shell integration cds into it.\n" +
+		"Detached worktrees are addressed by path or directory name.\n\n" +
+		"With --tmux or --zellij, attach to the worktree's multiplexer session instead,\n" +
+		"creating it (laid out by the session: config) if it is not running.",
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		branchName := args[0]
+		multiplexer, err := sessionFromFlags(cmd)
+		if err != nil {
+			return err
+		}
 
 		// Find the worktree path
 		worktrees, err := worktree.List()
@@ -20,16 +29,14 @@ var switchCmd = &cobra.Command{
 			return err
 		}
 
-		var targetPath string
-		for _, wt := range worktrees {
-			if wt.Branch == branchName {
-				targetPath = wt.Path
-				break
-			}
+		wt, err := worktree.Find(worktrees, branchName)
+		if err != nil {
+			return err
 		}
+		targetPath := wt.Path
 
-		if targetPath == "" {
-			return fmt.Errorf("worktree for branch '%s' not found", branchName)
+		if multiplexer != "" {
+			return openSession(multiplexer, targetPath, wt.Name())
 		}
 
 		// Output the path for shell function to cd to
@@ -40,4 +47,5 @@ var switchCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(switchCmd)
+	addSessionFlags(switchCmd)
 }
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nachoal/gwt/internal/journal"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [branch...]",
+	Short: "Rebase or merge worktrees onto their base branch",
+	Long: "Fetch once, then bring each worktree up to date with its base branch: the one\n" +
+		"recorded when the worktree was created, or the repository default branch.\n\n" +
+		"Branches are rebased onto the base's upstream (e.g. origin/main) when it has one,\n" +
+		"or merged with --merge. Worktrees with uncommitted changes are skipped unless\n" +
+		"--autostash is given. If a worktree hits conflicts, the rebase or merge is aborted\n" +
+		"and the worktree is left as it was. Without branches, the current worktree is synced.",
+	Example: "  gwt sync\n" +
+		"  gwt sync feature/a feature/b\n" +
+		"  gwt sync --all --autostash",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		base, _ := cmd.Flags().GetString("base")
+		merge, _ := cmd.Flags().GetBool("merge")
+		autostash, _ := cmd.Flags().GetBool("autostash")
+		noFetch, _ := cmd.Flags().GetBool("no-fetch")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		plain, _ := cmd.Flags().GetBool("plain")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		format, err := resolveOutputFormat(plain, jsonOut)
+		if err != nil {
+			return err
+		}
+		if all && len(args) > 0 {
+			return fmt.Errorf("--all cannot be combined with branches")
+		}
+		if jobs < 1 {
+			jobs = 1
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		defaultBase, err := worktree.GetDefaultBranch()
+		if err != nil {
+			return err
+		}
+
+		worktrees, err := worktree.List()
+		if err != nil {
+			return err
+		}
+		targets, err := syncTargets(worktrees, args, all, defaultBase)
+		if err != nil {
+			return err
+		}
+
+		if !noFetch {
+			if format == outputFormatPretty {
+				fmt.Fprintln(os.Stderr, infoStyle.Render("Fetching..."))
+			}
+			if err := worktree.FetchAll(); err != nil {
+				return err
+			}
+		}
+
+		// Branches other worktrees are based on are bases themselves; rebasing
+		// e.g. develop onto main is never what --all means.
+		bases := map[string]bool{defaultBase: true}
+		targetBases := make([]string, len(targets))
+		for i, wt := range targets {
+			targetBases[i] = base
+			if targetBases[i] == "" {
+				targetBases[i] = worktree.RecordedBase(wt.Path)
+			}
+			if targetBases[i] == "" {
+				targetBases[i] = defaultBase
+			}
+		}
+		for _, wt := range worktrees {
+			if b := worktree.RecordedBase(wt.Path); b != "" {
+				bases[b] = true
+			}
+		}
+
+		opts := worktree.SyncOptions{Merge: merge, Autostash: autostash}
+		results := make([]worktree.SyncResult, len(targets))
+		sem := make(chan struct{}, jobs)
+		var wg sync.WaitGroup
+		for i, wt := range targets {
+			wtBase := targetBases[i]
+			if wt.Branch == wtBase || (all && bases[wt.Branch]) {
+				results[i] = worktree.SyncResult{Branch: wt.Branch, Path: wt.Path, Base: wtBase, Status: worktree.SyncSkipped, Detail: "is a base branch"}
+				continue
+			}
+			if wt.Prunable {
+				results[i] = worktree.SyncResult{Branch: wt.Branch, Path: wt.Path, Base: wtBase, Status: worktree.SyncSkipped, Detail: "stale worktree (" + wt.PrunableReason + ")"}
+				continue
+			}
+			wg.Add(1)
+			go func(i int, wt worktree.Worktree) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				results[i] = worktree.Sync(wt, wtBase, worktree.SyncTarget(wtBase), opts)
+			}(i, wt)
+		}
+		wg.Wait()
+
+		commonGitDir, _ := worktree.CurrentCommonGitDir()
+		entry := journal.New("sync", args)
+		failed := 0
+		for _, r := range results {
+			entry.AddBranch(r.Branch, r.Before, r.After)
+			if r.Status == worktree.SyncConflict || r.Status == worktree.SyncFailed {
+				failed++
+			}
+		}
+		recordJournal(commonGitDir, entry)
+
+		if err := printSyncResults(results, format); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d worktree(s) could not be synced", failed)
+		}
+		return nil
+	},
+}
+
+// syncTargets picks the worktrees to sync: the named branches, every branch
+// worktree except the base's with --all, or the current worktree.
+func syncTargets(worktrees []worktree.Worktree, args []string, all bool, base string) ([]worktree.Worktree, error) {
+	if all {
+		var targets []worktree.Worktree
+		for _, wt := range worktrees {
+			if wt.Branch != base {
+				targets = append(targets, wt)
+			}
+		}
+		return targets, nil
+	}
+	if len(args) == 0 {
+		wt, err := statusTarget(worktrees, nil)
+		if err != nil {
+			return nil, err
+		}
+		return []worktree.Worktree{wt}, nil
+	}
+	targets := make([]worktree.Worktree, 0, len(args))
+	for _, branch := range args {
+		wt, err := statusTarget(worktrees, []string{branch})
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, wt)
+	}
+	return targets, nil
+}
+
+func printSyncResults(results []worktree.SyncResult, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case outputFormatPlain:
+		fmt.Println("branch\tbase\tstatus\tdetail")
+		for _, r := range results {
+			fmt.Printf("%s\t%s\t%s\t%s\n", r.Branch, r.Base, r.Status, r.Detail)
+		}
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Println(infoStyle.Render("No worktrees to sync"))
+		return nil
+	}
+	maxBranch := len("(detached)")
+	for _, r := range results {
+		if len(r.Branch) > maxBranch {
+			maxBranch = len(r.Branch)
+		}
+	}
+	fmt.Println(titleStyle.Render("Sync"))
+	fmt.Println(infoStyle.Render(fmt.Sprintf("   %-*s  %-10s  %s", maxBranch, "Branch", "Status", "Detail")))
+	for _, r := range results {
+		mark := checkMark
+		switch r.Status {
+		case worktree.SyncSkipped:
+			mark = infoStyle.Render("-")
+		case worktree.SyncConflict, worktree.SyncFailed:
+			mark = xMark
+		}
+		branch := r.Branch
+		if branch == "" {
+			branch = "(detached)"
+		}
+		detail := r.Detail
+		if detail == "" && r.Before != r.After {
+			detail = shortSHA(r.Before) + " → " + shortSHA(r.After)
+		}
+		fmt.Printf(" %s %-*s  %-10s  %s\n", mark, maxBranch, branch, r.Status, infoStyle.Render(detail))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().Bool("all", false, "Sync every worktree of the repository")
+	syncCmd.Flags().String("base", "", "Base branch to sync onto (defaults to each worktree's recorded base, then the repository default branch)")
+	syncCmd.Flags().Bool("merge", false, "Merge the base instead of rebasing onto it")
+	syncCmd.Flags().Bool("autostash", false, "Stash uncommitted changes around the rebase or merge instead of skipping the worktree")
+	syncCmd.Flags().Bool("no-fetch", false, "Do not fetch before syncing")
+	syncCmd.Flags().IntP("jobs", "j", 4, "Number of worktrees to sync concurrently")
+	syncCmd.Flags().Bool("plain", false, "Plain text output without styling")
+	syncCmd.Flags().Bool("json", false, "Machine-readable JSON output")
+}
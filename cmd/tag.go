@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <branch> [tag...]",
+	Short: "Show, add or remove tags on a worktree",
+	Long: "Add tags to a worktree's metadata, or remove them with --remove.\n" +
+		"Without tags, print the worktree's tags. Filter with `gwt list --tag <tag>`.",
+	Example: "  gwt tag feature/foo review urgent\n" +
+		"  gwt tag feature/foo --remove urgent",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remove, _ := cmd.Flags().GetBool("remove")
+		branch, tags := args[0], args[1:]
+		for _, t := range tags {
+			if err := worktree.ValidateTag(t); err != nil {
+				return err
+			}
+		}
+
+		path, err := worktreePathForBranch(branch)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			return fmt.Errorf("worktree for branch '%s' not found", branch)
+		}
+
+		if len(tags) == 0 {
+			if remove {
+				return fmt.Errorf("--remove requires at least one tag")
+			}
+			m, err := worktree.ReadMetadata(path)
+			if err != nil {
+				return err
+			}
+			if m != nil && len(m.Tags) > 0 {
+				fmt.Println(strings.Join(m.Tags, " "))
+			}
+			return nil
+		}
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return worktree.UpdateMetadata(path, func(m *worktree.Metadata) {
+			if remove {
+				m.RemoveTags(tags...)
+			} else {
+				m.AddTags(tags...)
+			}
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.Flags().BoolP("remove", "d", false, "Remove the given tags instead of adding them")
+}
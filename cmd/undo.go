@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/journal"
+	"github.com/nachoal/gwt/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Reverse the last recorded gwt operation",
+	Long: "Reverse the most recent operation in the journal (see 'gwt history').\n\n" +
+		"Deleted branches are recreated at their old SHA, removed worktrees are re-added\n" +
+		"and worktrees created by 'gwt new' are removed again. Uncommitted changes in a\n" +
+		"removed worktree cannot be recovered. Branches that moved since the operation\n" +
+		"are left alone.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+
+		release, err := acquireRepoLock(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		commonGitDir, err := worktree.CurrentCommonGitDir()
+		if err != nil {
+			return fmt.Errorf("not in a git repository: %w", err)
+		}
+		entries, err := journal.Read(commonGitDir)
+		if err != nil {
+			return err
+		}
+		target := journal.LastUndoable(entries)
+		if target == nil {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Nothing to undo"))
+			return nil
+		}
+
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Undoing ")+target.Op+infoStyle.Render(" ("+target.ID+"): ")+summarizeEntry(*target))
+
+		entry := journal.New(journal.OpUndo, args)
+		entry.Undoes = target.ID
+		// Without a config, re-added worktrees are not registered under the root.
+		cfg, _ := config.LoadConfig()
+		err = undoEntry(cfg, commonGitDir, target, force, entry)
+		// Mark the target as undone even after a partial reversal so a retry
+		// does not replay the steps that already succeeded.
+		if err != nil && entry.Empty() {
+			return err
+		}
+		if e := journal.Append(commonGitDir, entry); e != nil {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not write operation journal: "+e.Error()))
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, successStyle.Render("✓")+" Undid "+target.Op)
+		return nil
+	},
+}
+
+// undoEntry reverses the changes recorded in target, recording what it did in entry.
+// Branches are restored first so that removed worktrees can be re-added on them,
+// and branches created by the operation are deleted last, once their worktree is gone.
+func undoEntry(cfg *config.Config, commonGitDir string, target *journal.Entry, force bool, entry *journal.Entry) error {
+	for i := len(target.Branches) - 1; i >= 0; i-- {
+		b := target.Branches[i]
+		if b.Before == "" {
+			continue
+		}
+		current := worktree.BranchSHA(commonGitDir, b.Name)
+		if current == b.Before {
+			continue
+		}
+		if current != b.After {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: branch ")+fileStyle.Render(b.Name)+infoStyle.Render(" changed since; leaving it at "+shortSHA(current)))
+			continue
+		}
+
+		if b.After == "" {
+			if err := worktree.CreateBranchAt(commonGitDir, b.Name, b.Before); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "  "+checkMark+" Restored branch "+fileStyle.Render(b.Name)+" at "+shortSHA(b.Before))
+		} else {
+			path, err := worktreePathForBranch(b.Name)
+			if err != nil {
+				return err
+			}
+			if path != "" {
+				// Keep the checked-out worktree in sync; --keep refuses to clobber local changes.
+				if err := runGitInDir(path, "reset", "--keep", b.Before); err != nil {
+					return err
+				}
+			} else if err := worktree.MoveBranch(commonGitDir, b.Name, b.Before, b.After); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "  "+checkMark+" Reset branch "+fileStyle.Render(b.Name)+" to "+shortSHA(b.Before))
+		}
+		entry.AddBranch(b.Name, current, b.Before)
+	}
+
+	for i := len(target.Worktrees) - 1; i >= 0; i-- {
+		w := target.Worktrees[i]
+		switch w.Action {
+		case journal.ActionRemoved:
+			if w.Branch == "" {
+				fmt.Fprintln(os.Stderr, infoStyle.Render("Note: cannot re-add detached worktree ")+fileStyle.Render(w.Path))
+				continue
+			}
+			if _, err := os.Stat(w.Path); err == nil {
+				return fmt.Errorf("cannot re-add worktree: %s already exists", w.Path)
+			}
+			if err := worktree.Create(w.Branch, "", w.Path); err != nil {
+				return fmt.Errorf("failed to re-add worktree %s: %w", w.Path, err)
+			}
+			entry.AddWorktree(journal.ActionAdded, w.Path, w.Branch, worktree.BranchSHA(commonGitDir, w.Branch))
+			fmt.Fprintln(os.Stderr, "  "+checkMark+" Re-added worktree "+fileStyle.Render(w.Path))
+			registerReadded(cfg, w.Path, w.Branch)
+		case journal.ActionAdded:
+			if _, err := os.Stat(w.Path); os.IsNotExist(err) {
+				continue
+			}
+			head := worktree.BranchSHA(commonGitDir, w.Branch)
+			root := ""
+			if cfg != nil {
+				root = cfg.Settings.Root
+			}
+			if err := worktree.Remove(root, w.Path, force); err != nil {
+				return fmt.Errorf("failed to remove worktree %s (use --force to discard changes): %w", w.Path, err)
+			}
+			entry.AddWorktree(journal.ActionRemoved, w.Path, w.Branch, head)
+			fmt.Fprintln(os.Stderr, "  "+checkMark+" Removed worktree "+fileStyle.Render(w.Path))
+		}
+	}
+
+	for _, b := range target.Branches {
+		if b.Before != "" {
+			continue
+		}
+		current := worktree.BranchSHA(commonGitDir, b.Name)
+		if current == "" {
+			continue
+		}
+		if current != b.After {
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: branch ")+fileStyle.Render(b.Name)+infoStyle.Render(" has new commits; not deleting it"))
+			continue
+		}
+		// The branch is exactly where the operation left it, so nothing is lost by -D.
+		if err := worktree.DeleteBranchWithGitDir(commonGitDir, b.Name, true); err != nil {
+			return fmt.Errorf("failed to delete branch %s: %w", b.Name, err)
+		}
+		entry.AddBranch(b.Name, current, "")
+		fmt.Fprintln(os.Stderr, "  "+checkMark+" Deleted branch "+fileStyle.Render(b.Name))
+	}
+	return nil
+}
+
+// registerReadded records a re-added worktree in the root registry and
+// reserves its ports again, as 'gwt new' does.
+func registerReadded(cfg *config.Config, path, branch string) {
+	if cfg == nil {
+		return
+	}
+	projectName, err := worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+	if err == nil {
+		err = worktree.Register(cfg.Settings.Root, projectName, branch, path)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not record worktree in registry: "+err.Error()))
+		return
+	}
+	ports, err := worktree.AssignPorts(cfg.Settings.Root, path, cfg.Ports)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Note: could not reserve ports: "+err.Error()))
+		return
+	}
+	if len(ports) > 0 {
+		fmt.Fprintln(os.Stderr, "  "+checkMark+" Ports reserved: "+worktree.FormatPorts(ports))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().BoolP("force", "f", false, "Remove worktrees created by the undone operation even if they have uncommitted changes")
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -24,6 +25,8 @@ type versionInfo struct {
 	GoVersion  string `json:"go_version"`
 	Module     string `json:"module,omitempty"`
 	Executable string `json:"executable,omitempty"`
+	// OnPath lists the gwt executables found on PATH, in lookup order.
+	OnPath []string `json:"on_path,omitempty"`
 }
 
 func shortVersion() string {
@@ -57,6 +60,19 @@ var versionCmd = &cobra.Command{
 		if info.Executable != "" {
 			fmt.Printf("executable: %s\n", info.Executable)
 		}
+		if len(info.OnPath) > 1 {
+			for i, p := range info.OnPath {
+				label := "on PATH:   "
+				if i > 0 {
+					label = "           "
+				}
+				if p == info.Executable {
+					p += " (this binary)"
+				}
+				fmt.Printf("%s%s\n", label, p)
+			}
+			fmt.Fprintln(os.Stderr, infoStyle.Render("Note: several gwt binaries are on PATH; the first one runs (see 'gwt doctor')"))
+		}
 		return nil
 	},
 }
@@ -77,6 +93,8 @@ func getVersionInfo() versionInfo {
 		}
 	}
 
+	info.OnPath = gwtOnPath()
+
 	if bi, ok := debug.ReadBuildInfo(); ok {
 		if info.Module == "" && bi.Main.Path != "" {
 			info.Module = bi.Main.Path
@@ -101,6 +119,53 @@ func getVersionInfo() versionInfo {
 	return info
 }
 
+// gwtOnPath returns the distinct gwt executables on PATH (symlinks resolved)
+// in the order the shell looks them up.
+func gwtOnPath() []string {
+	return executablesOnPath("gwt", runtime.GOOS, os.Getenv("PATH"), os.Getenv("PATHEXT"))
+}
+
+// executablesOnPath finds every executable called name in path, the way
+// exec.LookPath finds the first: on Windows with one of the pathext
+// extensions (".exe" and so on), elsewhere with an executable bit.
+func executablesOnPath(name, goos, path, pathext string) []string {
+	names := []string{name}
+	if goos == "windows" {
+		if pathext == "" {
+			pathext = ".com;.exe;.bat;.cmd"
+		}
+		names = nil
+		for _, ext := range strings.Split(strings.ToLower(pathext), ";") {
+			if ext != "" {
+				names = append(names, name+ext)
+			}
+		}
+	}
+
+	var found []string
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		for _, n := range names {
+			p := filepath.Join(dir, n)
+			fi, err := os.Stat(p)
+			if err != nil || fi.IsDir() || (goos != "windows" && fi.Mode()&0o111 == 0) {
+				continue
+			}
+			if resolved, err := filepath.EvalSymlinks(p); err == nil {
+				p = resolved
+			}
+			if !seen[p] {
+				seen[p] = true
+				found = append(found, p)
+			}
+		}
+	}
+	return found
+}
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
 	versionCmd.Flags().Bool("json", false, "Machine-readable JSON output")
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecutablesOnPath(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]os.FileMode{
+		"a/gwt":     0o755,
+		"b/gwt":     0o644, // not executable
+		"c/gwt.exe": 0o644,
+		"d/gwt.cmd": 0o644,
+		"e/gwt":     0o755,
+		"f/gwt.txt": 0o755,
+	}
+	for name, mode := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A directory named like the binary is skipped, a symlink to a found
+	// binary is reported once.
+	if err := os.MkdirAll(filepath.Join(root, "g", "gwt"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "h"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "a", "gwt"), filepath.Join(root, "h", "gwt")); err != nil {
+		t.Fatal(err)
+	}
+
+	var dirs []string
+	for _, d := range []string{"a", "b", "c", "d", "e", "f", "g", "h", ""} {
+		if d != "" {
+			d = filepath.Join(root, d)
+		}
+		dirs = append(dirs, d)
+	}
+	path := strings.Join(dirs, string(os.PathListSeparator))
+	resolved := func(names ...string) []string {
+		var out []string
+		for _, n := range names {
+			p, err := filepath.EvalSymlinks(filepath.Join(root, n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, p)
+		}
+		return out
+	}
+
+	tests := []struct {
+		goos, pathext string
+		want          []string
+	}{
+		{goos: "linux", want: resolved("a/gwt", "e/gwt")},
+		{goos: "windows", want: resolved("c/gwt.exe", "d/gwt.cmd")},
+		{goos: "windows", pathext: ".EXE", want: resolved("c/gwt.exe")},
+	}
+	for _, tt := range tests {
+		if got := executablesOnPath("gwt", tt.goos, path, tt.pathext); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s, PATHEXT %q: got %q, want %q", tt.goos, tt.pathext, got, tt.want)
+		}
+	}
+}
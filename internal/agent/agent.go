@@ -0,0 +1,134 @@
+// Package agent launches coding agents (claude, codex, custom scripts) in worktrees.
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/shellwords"
+)
+
+// Default is the agent name that stands for settings.agent.
+const Default = "default"
+
+// fallback is the agent used when settings.agent is empty.
+const fallback = "claude"
+
+// builtins are available without configuration; an agents: entry with the
+// same name replaces them.
+var builtins = map[string]config.AgentConfig{
+	"claude": {Command: "claude", IssuePrompt: "/issue-analysis {{.Issue}}", Batch: "claude -p {{.Prompt}}"},
+	"codex":  {Command: "codex", Batch: "codex exec {{.Prompt}}"},
+}
+
+// defaultIssuePrompt is used for --issue when the agent has no issue_prompt.
+const defaultIssuePrompt = "Analyze and resolve this issue: {{.Issue}}"
+
+// Data is available to agent commands and prompt templates.
+type Data struct {
+	Prompt  string
+	Issue   string
+	Branch  string
+	Path    string
+	Project string
+}
+
+// Resolve looks up the agent called name in agents and the built-ins. Default
+// (or "") resolves to defaultName, which falls back to claude.
+func Resolve(agents map[string]config.AgentConfig, defaultName, name string) (string, config.AgentConfig, error) {
+	if name == "" || name == Default {
+		name = defaultName
+		if name == "" {
+			name = fallback
+		}
+	}
+	if a, ok := agents[name]; ok {
+		if strings.TrimSpace(a.Command) == "" {
+			return name, a, fmt.Errorf("agent '%s' has no command", name)
+		}
+		return name, a, nil
+	}
+	if a, ok := builtins[name]; ok {
+		return name, a, nil
+	}
+	return name, config.AgentConfig{}, fmt.Errorf("unknown agent '%s' (known: %s)", name, strings.Join(Names(agents), ", "))
+}
+
+// Names lists the configured and built-in agent names, sorted.
+func Names(agents map[string]config.AgentConfig) []string {
+	seen := map[string]bool{}
+	var names []string
+	for name := range agents {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for name := range builtins {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Command returns the command launching agent a in data.Path. When
+// data.Prompt is empty and data.Issue is set, the prompt comes from the
+// agent's issue prompt template. Stdio and environment are left to the caller.
+func Command(a config.AgentConfig, data Data) (*exec.Cmd, error) {
+	if data.Prompt == "" && data.Issue != "" {
+		tmpl := a.IssuePrompt
+		if tmpl == "" {
+			tmpl = defaultIssuePrompt
+		}
+		prompt, err := render("issue_prompt", tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		data.Prompt = prompt
+	}
+
+	argv, used, err := shellwords.Expand(a.Command, map[string]string{
+		"Prompt":  data.Prompt,
+		"Issue":   data.Issue,
+		"Branch":  data.Branch,
+		"Path":    data.Path,
+		"Project": data.Project,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent command %q: %w", a.Command, err)
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("agent command is empty")
+	}
+	if !used["Prompt"] && data.Prompt != "" {
+		argv = append(argv, data.Prompt)
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = data.Path
+	return cmd, nil
+}
+
+// Batch returns a with its batch command, for runs without a terminal.
+func Batch(a config.AgentConfig) config.AgentConfig {
+	if a.Batch != "" {
+		a.Command = a.Batch
+	}
+	return a
+}
+
+func render(name, text string, data Data) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid agent %s %q: %w", name, text, err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid agent %s %q: %w", name, text, err)
+	}
+	return b.String(), nil
+}
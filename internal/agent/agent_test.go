@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nachoal/gwt/internal/config"
+)
+
+func TestCommand(t *testing.T) {
+	data := Data{Prompt: "fix the \"login\" bug", Branch: "feat/login", Path: "/wt/repo/feat/login", Project: "repo"}
+	tests := []struct {
+		command string
+		issue   string
+		want    []string
+		wantErr bool
+	}{
+		{command: "claude", want: []string{"claude", data.Prompt}},
+		{command: "claude -p {{.Prompt}}", want: []string{"claude", "-p", data.Prompt}},
+		{command: "claude -p {{ .Prompt }}", want: []string{"claude", "-p", data.Prompt}},
+		{command: `agent --title "my agent" --branch {{ .Branch }}`, want: []string{"agent", "--title", "my agent", "--branch", "feat/login", data.Prompt}},
+		{command: `agent --name '{{.Project}}: {{.Branch}}' {{.Prompt}}`, want: []string{"agent", "--name", "repo: feat/login", data.Prompt}},
+		{command: "claude {{ .Prompt", wantErr: true},
+		{command: "claude {{.Unknown}}", wantErr: true},
+		{command: `claude "open`, wantErr: true},
+		{command: "  ", wantErr: true},
+	}
+	for _, tt := range tests {
+		cmd, err := Command(config.AgentConfig{Command: tt.command}, data)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Command(%q) error = %v, wantErr %t", tt.command, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(cmd.Args, tt.want) || cmd.Dir != data.Path {
+			t.Errorf("Command(%q) = %q in %s, want %q", tt.command, cmd.Args, cmd.Dir, tt.want)
+		}
+	}
+}
+
+func TestCommandIssuePrompt(t *testing.T) {
+	a := config.AgentConfig{Command: "claude -p {{ .Prompt }}", IssuePrompt: "Resolve {{ .Issue }} on {{ .Branch }}"}
+	cmd, err := Command(a, Data{Issue: "#42", Branch: "fix/42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"claude", "-p", "Resolve #42 on fix/42"}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("args = %q, want %q", cmd.Args, want)
+	}
+}
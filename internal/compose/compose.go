@@ -0,0 +1,62 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Stack is a Docker Compose project scoped to one worktree.
+type Stack struct {
+	// Project is the COMPOSE_PROJECT_NAME, unique per worktree.
+	Project  string   `json:"project"`
+	Files    []string `json:"files,omitempty"`
+	Services []string `json:"services,omitempty"`
+	// Dir is the worktree the stack runs from; it is not persisted.
+	Dir string `json:"-"`
+}
+
+// Up starts the stack in the background (`docker compose up -d`). env is
+// added to the environment, so compose files can use e.g. ${PORT_WEB}.
+func (s Stack) Up(env []string) error {
+	args := append(s.baseArgs(), "up", "-d")
+	return s.run(env, append(args, s.Services...)...)
+}
+
+// Down stops the stack and removes its containers, networks and volumes.
+// env should match what Up was given so that the compose files interpolate.
+func (s Stack) Down(env []string) error {
+	return s.run(env, append(s.baseArgs(), "down", "-v", "--remove-orphans")...)
+}
+
+// Running returns the number of running containers in the stack.
+func (s Stack) Running(env []string) (int, error) {
+	cmd := exec.Command("docker", append(s.baseArgs(), "ps", "-q", "--status", "running")...)
+	cmd.Dir = s.Dir
+	cmd.Env = append(append(os.Environ(), "COMPOSE_PROJECT_NAME="+s.Project), env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("docker compose ps failed: %w", err)
+	}
+	return len(strings.Fields(string(out))), nil
+}
+
+func (s Stack) baseArgs() []string {
+	args := []string{"compose", "-p", s.Project}
+	for _, f := range s.Files {
+		args = append(args, "-f", f)
+	}
+	return args
+}
+
+func (s Stack) run(env []string, args ...string) error {
+	cmd := exec.Command("docker", args...)
+	cmd.Dir = s.Dir
+	cmd.Env = append(append(os.Environ(), "COMPOSE_PROJECT_NAME="+s.Project), env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
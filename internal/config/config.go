@@ -1,25 +1,91 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Version  int      `yaml:"version"`
-	Copy     []string `yaml:"copy"`
-	Setup    []string `yaml:"setup"`
-	Settings Settings `yaml:"settings"`
+	Version int      `yaml:"version"`
+	Copy    []string `yaml:"copy"`
+	Setup   []string `yaml:"setup"`
+	// Ports maps service names to base ports; each worktree gets a unique
+	// offset added to them, written to .env.gwt as PORT_<NAME>.
+	Ports map[string]int `yaml:"ports,omitempty"`
+	// Templates maps source files (relative to the main worktree) to
+	// destinations in new worktrees, rendered with text/template.
+	Templates map[string]string `yaml:"templates,omitempty"`
+	// Compose starts a Docker Compose stack per worktree when set.
+	Compose *ComposeConfig `yaml:"compose,omitempty"`
+	// Session lays out the tmux session or zellij tab opened with --tmux/--zellij.
+	Session *SessionConfig `yaml:"session,omitempty"`
+	// Agents are named coding agents `gwt new --agent` and `gwt agent` launch.
+	Agents   map[string]AgentConfig `yaml:"agents,omitempty"`
+	Settings Settings               `yaml:"settings"`
 }
 
 type Settings struct {
 	Root            string `yaml:"root"`
 	AutoCleanMerged bool   `yaml:"auto_clean_merged"`
 	ConfirmDelete   bool   `yaml:"confirm_delete"`
+	// PathTemplate is a text/template for worktree paths with .Root, .Project
+	// and .Branch, plus the slug function. Empty means {{.Root}}/{{.Project}}/{{.Branch}}.
+	PathTemplate string `yaml:"path_template,omitempty"`
+	// Project overrides the project directory name derived from the remote.
+	Project string `yaml:"project,omitempty"`
+	// Forge selects pull request integration: auto (default), github, gitlab,
+	// none or file:<path>.
+	Forge string `yaml:"forge,omitempty"`
+	// Editor is what `gwt open` launches: code, cursor, zed, idea, nvim, vim
+	// or a command line ({{.Path}} marks the path). Empty uses $VISUAL/$EDITOR.
+	Editor string `yaml:"editor,omitempty"`
+	// EditorWindow is "new" or "reuse" for editors with window flags; empty
+	// keeps the editor's default.
+	EditorWindow string `yaml:"editor_window,omitempty"`
+	// Agent names the agent used by `--agent default` and the shell's -c;
+	// empty means claude.
+	Agent string `yaml:"agent,omitempty"`
+}
+
+// AgentConfig defines how to launch a coding agent in a worktree.
+type AgentConfig struct {
+	// Command is the agent's command line. {{.Prompt}} marks where the prompt
+	// goes; otherwise a non-empty prompt is appended as one argument.
+	Command string `yaml:"command"`
+	// IssuePrompt is the prompt template used for --issue without --prompt,
+	// e.g. "/issue-analysis {{.Issue}}".
+	IssuePrompt string `yaml:"issue_prompt,omitempty"`
+	// Batch is the non-interactive command `gwt spawn` runs detached, e.g.
+	// "claude -p {{.Prompt}}"; empty uses Command.
+	Batch string `yaml:"batch,omitempty"`
+}
+
+// ComposeConfig configures the per-worktree Docker Compose stack.
+type ComposeConfig struct {
+	// Files are compose files relative to the worktree; empty uses compose's defaults.
+	Files []string `yaml:"files,omitempty"`
+	// Services limits `up` to these services; empty starts all of them.
+	Services []string `yaml:"services,omitempty"`
+}
+
+// SessionConfig configures multiplexer sessions opened for worktrees.
+type SessionConfig struct {
+	// Windows are created in order (zellij: panes); empty gives one shell.
+	Windows []SessionWindow `yaml:"windows,omitempty"`
+}
+
+// SessionWindow is one window of a session; Command is typed into its shell.
+type SessionWindow struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command,omitempty"`
 }
 
 func DefaultConfig() *Config {
@@ -119,5 +185,86 @@ func SaveConfig(config *Config) error {
 		return err
 	}
 
-	return os.WriteFile(".worktree.yaml", data, 0644)
+	// Write to a temp file and rename so concurrent readers never see a
+	// partially written config.
+	tmp, err := os.CreateTemp(".", ".worktree.yaml.tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), ".worktree.yaml")
+}
+
+// Lint reports problems in the config file at path that LoadConfig accepts
+// silently: unknown keys (usually typos) and invalid values. A missing file
+// has no problems; a file that does not parse is returned as an error.
+func Lint(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var strict Config
+	if err := dec.Decode(&strict); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			problems = append(problems, typeErr.Errors...)
+		} else if err != io.EOF {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	switch cfg.Settings.EditorWindow {
+	case "", "new", "reuse":
+	default:
+		problems = append(problems, fmt.Sprintf("settings.editor_window must be new or reuse, not %q", cfg.Settings.EditorWindow))
+	}
+	owners := make(map[int][]string)
+	for name, port := range cfg.Ports {
+		if port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("ports.%s: %d is not a valid port", name, port))
+		}
+		owners[port] = append(owners[port], name)
+	}
+	for port, names := range owners {
+		if len(names) > 1 {
+			sort.Strings(names)
+			problems = append(problems, fmt.Sprintf("ports: %s share base port %d", strings.Join(names, ", "), port))
+		}
+	}
+	dir := filepath.Dir(path)
+	for src := range cfg.Templates {
+		if _, err := os.Stat(filepath.Join(dir, src)); err != nil {
+			problems = append(problems, fmt.Sprintf("templates: source %s does not exist", src))
+		}
+	}
+	for name, a := range cfg.Agents {
+		if strings.TrimSpace(a.Command) == "" {
+			problems = append(problems, fmt.Sprintf("agents.%s has no command", name))
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
 }
@@ -0,0 +1,98 @@
+// Package editor builds the command that opens a worktree in an editor or IDE.
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/shellwords"
+)
+
+const (
+	// WindowNew opens a new editor window; WindowReuse reuses the last one.
+	WindowNew   = "new"
+	WindowReuse = "reuse"
+)
+
+// preset describes how to invoke a known editor. Editors without window
+// flags leave the choice to the editor.
+type preset struct {
+	argv        []string
+	newWindow   []string
+	reuseWindow []string
+}
+
+var presets = map[string]preset{
+	"code":   {argv: []string{"code"}, newWindow: []string{"--new-window"}, reuseWindow: []string{"--reuse-window"}},
+	"cursor": {argv: []string{"cursor"}, newWindow: []string{"--new-window"}, reuseWindow: []string{"--reuse-window"}},
+	"zed":    {argv: []string{"zed"}, newWindow: []string{"--new"}, reuseWindow: []string{"--add"}},
+	"idea":   {argv: []string{"idea"}},
+	"nvim":   {argv: []string{"nvim"}},
+	"vim":    {argv: []string{"vim"}},
+}
+
+// Presets returns the names of the built-in editors.
+func Presets() []string {
+	return []string{"code", "cursor", "zed", "idea", "nvim", "vim"}
+}
+
+// Command returns the command opening the worktree at path, run from path.
+// editor is a preset name or a command line, split like a shell would, in
+// which {{.Path}} marks where the path goes (it is appended otherwise); empty falls back to $VISUAL and
+// then $EDITOR. window is "", WindowNew or WindowReuse and only applies to
+// presets that support it. Stdio is left to the caller.
+func Command(editor, window, path string) (*exec.Cmd, error) {
+	switch window {
+	case "", WindowNew, WindowReuse:
+	default:
+		return nil, fmt.Errorf("invalid editor window %q (use %s or %s)", window, WindowNew, WindowReuse)
+	}
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if strings.TrimSpace(editor) == "" {
+		return nil, fmt.Errorf("no editor configured: set settings.editor (%s or a command) or $VISUAL", strings.Join(Presets(), ", "))
+	}
+
+	var argv []string
+	if p, ok := presets[editor]; ok {
+		argv = append(argv, p.argv...)
+		switch window {
+		case WindowNew:
+			argv = append(argv, p.newWindow...)
+		case WindowReuse:
+			argv = append(argv, p.reuseWindow...)
+		}
+		argv = append(argv, path)
+	} else {
+		var err error
+		if argv, err = expand(editor, path); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = path
+	return cmd, nil
+}
+
+// expand renders {{.Path}} in a command line and splits it into words with
+// shell quoting rules, appending the path when the line does not mention it.
+func expand(line, path string) ([]string, error) {
+	argv, used, err := shellwords.Expand(line, map[string]string{"Path": path})
+	if err != nil {
+		return nil, fmt.Errorf("invalid editor command %q: %w", line, err)
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("invalid editor command %q: no program", line)
+	}
+	if !used["Path"] {
+		argv = append(argv, path)
+	}
+	return argv, nil
+}
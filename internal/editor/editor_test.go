@@ -0,0 +1,41 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	const path = "/wt/my repo/feat"
+	tests := []struct {
+		editor  string
+		window  string
+		want    []string
+		wantErr bool
+	}{
+		{editor: "code", window: WindowNew, want: []string{"code", "--new-window", path}},
+		{editor: "zed", window: WindowReuse, want: []string{"zed", "--add", path}},
+		{editor: "nvim", window: WindowNew, want: []string{"nvim", path}},
+		{editor: "subl -n", want: []string{"subl", "-n", path}},
+		{editor: "subl -n {{.Path}}", want: []string{"subl", "-n", path}},
+		{editor: "subl -n {{ .Path }}", want: []string{"subl", "-n", path}},
+		{editor: `emacsclient -c -a "" {{.Path}}`, want: []string{"emacsclient", "-c", "-a", "", path}},
+		{editor: `open -a "Sublime Text"`, want: []string{"open", "-a", "Sublime Text", path}},
+		{editor: "code", window: "tab", wantErr: true},
+		{editor: "subl {{ .Path", wantErr: true},
+		{editor: "{{if false}}x{{end}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		cmd, err := Command(tt.editor, tt.window, path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Command(%q, %q) error = %v, wantErr %t", tt.editor, tt.window, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(cmd.Args, tt.want) || cmd.Dir != path {
+			t.Errorf("Command(%q, %q) = %q in %s, want %q", tt.editor, tt.window, cmd.Args, cmd.Dir, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package forge
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// File reads pull requests from a JSON file containing a list of PullRequest
+// objects. It stands in for a real forge in tests and offline scripts.
+type File struct {
+	Path string
+}
+
+func (f *File) Name() string { return "file" }
+
+// PullRequest returns the last entry in the file for branch, so appending an
+// entry supersedes earlier ones.
+func (f *File) PullRequest(branch string) (*PullRequest, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var prs []PullRequest
+	if err := json.Unmarshal(data, &prs); err != nil {
+		return nil, err
+	}
+	var found *PullRequest
+	for i := range prs {
+		if prs[i].Branch == branch {
+			p := prs[i]
+			p.State = normalizeState(p.State)
+			found = &p
+		}
+	}
+	return found, nil
+}
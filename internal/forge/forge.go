@@ -0,0 +1,109 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Pull/merge request states, normalized across forges.
+const (
+	StateOpen   = "open"
+	StateMerged = "merged"
+	StateClosed = "closed"
+)
+
+// PullRequest is a GitHub pull request or GitLab merge request.
+type PullRequest struct {
+	Number int    `json:"number"`
+	State  string `json:"state"`
+	Branch string `json:"branch"`
+	Title  string `json:"title,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// Label is a short summary such as "#12 merged", or "-" without a pull request.
+func (pr *PullRequest) Label() string {
+	if pr == nil {
+		return "-"
+	}
+	return fmt.Sprintf("#%d %s", pr.Number, pr.State)
+}
+
+// Provider looks up pull/merge requests on a forge.
+type Provider interface {
+	// Name identifies the provider ("github", "gitlab", "file").
+	Name() string
+	// PullRequest returns the most recent pull request whose head is branch,
+	// or nil if there is none.
+	PullRequest(branch string) (*PullRequest, error)
+}
+
+// New returns the provider selected by setting (settings.forge, or the
+// GWT_FORGE environment variable when set):
+//
+//   - "" or "auto": GitHub or GitLab depending on the remote URL, when the
+//     matching CLI (gh / glab) is installed; otherwise no provider
+//   - "github" / "gitlab": always use that forge's CLI
+//   - "file:<path>": read pull requests from a JSON file (for tests and scripts)
+//   - "none": disable forge integration
+//
+// A nil Provider with a nil error means forge integration is off.
+func New(setting, remoteURL, dir string) (Provider, error) {
+	if env := strings.TrimSpace(os.Getenv("GWT_FORGE")); env != "" {
+		setting = env
+	}
+	setting = strings.TrimSpace(setting)
+
+	switch {
+	case setting == "none":
+		return nil, nil
+	case setting == "github":
+		return &GitHub{Dir: dir}, nil
+	case setting == "gitlab":
+		return &GitLab{Dir: dir}, nil
+	case strings.HasPrefix(setting, "file:"):
+		return &File{Path: strings.TrimPrefix(setting, "file:")}, nil
+	case setting == "" || setting == "auto":
+		host := strings.ToLower(remoteURL)
+		switch {
+		case strings.Contains(host, "github"):
+			if _, err := exec.LookPath("gh"); err == nil {
+				return &GitHub{Dir: dir}, nil
+			}
+		case strings.Contains(host, "gitlab"):
+			if _, err := exec.LookPath("glab"); err == nil {
+				return &GitLab{Dir: dir}, nil
+			}
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown forge %q (expected auto, github, gitlab, none or file:<path>)", setting)
+}
+
+// normalizeState maps forge-specific state names onto the State* constants.
+func normalizeState(s string) string {
+	switch strings.ToLower(s) {
+	case "open", "opened":
+		return StateOpen
+	case "merged":
+		return StateMerged
+	case "closed", "locked":
+		return StateClosed
+	}
+	return strings.ToLower(s)
+}
+
+func runCLI(dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, fmt.Errorf("%s %s: %s", name, strings.Join(args[:2], " "), strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,37 @@
+package forge
+
+import "encoding/json"
+
+// GitHub looks up pull requests with the gh CLI.
+type GitHub struct {
+	// Dir is the repository directory gh runs in.
+	Dir string
+}
+
+func (g *GitHub) Name() string { return "github" }
+
+func (g *GitHub) PullRequest(branch string) (*PullRequest, error) {
+	out, err := runCLI(g.Dir, "gh", "pr", "list",
+		"--head", branch,
+		"--state", "all",
+		"--limit", "1",
+		"--json", "number,state,title,url,headRefName")
+	if err != nil {
+		return nil, err
+	}
+	var prs []struct {
+		Number      int    `json:"number"`
+		State       string `json:"state"`
+		Title       string `json:"title"`
+		URL         string `json:"url"`
+		HeadRefName string `json:"headRefName"`
+	}
+	if err := json.Unmarshal(out, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	p := prs[0]
+	return &PullRequest{Number: p.Number, State: normalizeState(p.State), Branch: p.HeadRefName, Title: p.Title, URL: p.URL}, nil
+}
@@ -0,0 +1,37 @@
+package forge
+
+import "encoding/json"
+
+// GitLab looks up merge requests with the glab CLI.
+type GitLab struct {
+	// Dir is the repository directory glab runs in.
+	Dir string
+}
+
+func (g *GitLab) Name() string { return "gitlab" }
+
+func (g *GitLab) PullRequest(branch string) (*PullRequest, error) {
+	out, err := runCLI(g.Dir, "glab", "mr", "list",
+		"--source-branch", branch,
+		"--all",
+		"--per-page", "1",
+		"--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var mrs []struct {
+		IID          int    `json:"iid"`
+		State        string `json:"state"`
+		Title        string `json:"title"`
+		WebURL       string `json:"web_url"`
+		SourceBranch string `json:"source_branch"`
+	}
+	if err := json.Unmarshal(out, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	m := mrs[0]
+	return &PullRequest{Number: m.IID, State: normalizeState(m.State), Branch: m.SourceBranch, Title: m.Title, URL: m.WebURL}, nil
+}
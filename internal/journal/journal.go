@@ -0,0 +1,158 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Worktree actions recorded in a WorktreeChange.
+const (
+	ActionAdded   = "added"
+	ActionRemoved = "removed"
+)
+
+// OpUndo is the operation name used for entries written by `gwt undo`.
+const OpUndo = "undo"
+
+// Entry is a single mutating gwt operation recorded in the journal.
+type Entry struct {
+	ID        string           `json:"id"`
+	Time      time.Time        `json:"time"`
+	Op        string           `json:"op"`
+	Args      []string         `json:"args,omitempty"`
+	Branches  []BranchChange   `json:"branches,omitempty"`
+	Worktrees []WorktreeChange `json:"worktrees,omitempty"`
+	Undoes    string           `json:"undoes,omitempty"`
+}
+
+// BranchChange records where a branch pointed before and after an operation.
+// An empty Before means the branch was created; an empty After means it was deleted.
+type BranchChange struct {
+	Name   string `json:"name"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// WorktreeChange records a worktree that was added or removed.
+type WorktreeChange struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	Branch string `json:"branch,omitempty"`
+	Head   string `json:"head,omitempty"`
+}
+
+// New returns an empty entry for op, stamped with the current time.
+func New(op string, args []string) *Entry {
+	now := time.Now()
+	return &Entry{
+		ID:   strconv.FormatInt(now.UnixNano(), 36),
+		Time: now.UTC(),
+		Op:   op,
+		Args: append([]string(nil), args...),
+	}
+}
+
+// AddBranch records a branch change. Changes where nothing moved are ignored.
+func (e *Entry) AddBranch(name, before, after string) {
+	if name == "" || before == after {
+		return
+	}
+	e.Branches = append(e.Branches, BranchChange{Name: name, Before: before, After: after})
+}
+
+// AddWorktree records a worktree that was added or removed.
+func (e *Entry) AddWorktree(action, path, branch, head string) {
+	e.Worktrees = append(e.Worktrees, WorktreeChange{Action: action, Path: path, Branch: branch, Head: head})
+}
+
+// Empty reports whether the entry recorded no changes.
+func (e *Entry) Empty() bool {
+	return len(e.Branches) == 0 && len(e.Worktrees) == 0
+}
+
+// Path returns the journal file location for a common git dir.
+func Path(commonGitDir string) string {
+	return filepath.Join(commonGitDir, "gwt", "journal.jsonl")
+}
+
+// Append writes an entry to the journal of the given common git dir.
+// Entries without changes are not written, except undo markers.
+func Append(commonGitDir string, e *Entry) error {
+	if e == nil || (e.Empty() && e.Undoes == "") {
+		return nil
+	}
+	path := Path(commonGitDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Read returns all journal entries, oldest first. A missing journal is empty.
+func Read(commonGitDir string) ([]Entry, error) {
+	f, err := os.Open(Path(commonGitDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("corrupt journal entry at line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Undone returns the set of entry IDs that have already been reversed.
+func Undone(entries []Entry) map[string]bool {
+	undone := make(map[string]bool)
+	for _, e := range entries {
+		if e.Op == OpUndo && e.Undoes != "" {
+			undone[e.Undoes] = true
+		}
+	}
+	return undone
+}
+
+// LastUndoable returns the most recent entry that is neither an undo nor
+// already undone, or nil if there is nothing left to reverse.
+func LastUndoable(entries []Entry) *Entry {
+	undone := Undone(entries)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Op == OpUndo || undone[e.ID] {
+			continue
+		}
+		return &entries[i]
+	}
+	return nil
+}
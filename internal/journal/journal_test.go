@@ -0,0 +1,106 @@
+package journal
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func entry(id, op, undoes string) Entry {
+	return Entry{ID: id, Op: op, Undoes: undoes}
+}
+
+func TestUndone(t *testing.T) {
+	entries := []Entry{
+		entry("a", "new", ""),
+		entry("b", "remove", ""),
+		entry("u1", OpUndo, "b"),
+		entry("u2", OpUndo, ""),
+		entry("c", "done", "x"),
+	}
+	want := map[string]bool{"b": true}
+	if got := Undone(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("Undone() = %v, want %v", got, want)
+	}
+}
+
+func TestLastUndoable(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    string
+	}{
+		{name: "empty", entries: nil, want: ""},
+		{name: "latest", entries: []Entry{entry("a", "new", ""), entry("b", "remove", "")}, want: "b"},
+		{
+			name:    "skips undone entries and undo markers",
+			entries: []Entry{entry("a", "new", ""), entry("b", "remove", ""), entry("u1", OpUndo, "b")},
+			want:    "a",
+		},
+		{
+			name:    "repeated undos walk back",
+			entries: []Entry{entry("a", "new", ""), entry("b", "remove", ""), entry("u1", OpUndo, "b"), entry("u2", OpUndo, "a")},
+			want:    "",
+		},
+		{
+			name:    "new operations after an undo come first",
+			entries: []Entry{entry("a", "new", ""), entry("u1", OpUndo, "a"), entry("c", "sync", "")},
+			want:    "c",
+		},
+		{
+			name:    "an undo marker for an unknown entry changes nothing",
+			entries: []Entry{entry("a", "new", ""), entry("u1", OpUndo, "zzz")},
+			want:    "a",
+		},
+	}
+	for _, tt := range tests {
+		got := LastUndoable(tt.entries)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("%s: LastUndoable() = %s, want nil", tt.name, got.ID)
+		case tt.want != "" && (got == nil || got.ID != tt.want):
+			t.Errorf("%s: LastUndoable() = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	dir := t.TempDir()
+	if entries, err := Read(dir); err != nil || len(entries) != 0 {
+		t.Fatalf("Read() of a missing journal = %v, %v", entries, err)
+	}
+
+	changed := New("new", []string{"feat"})
+	changed.AddBranch("feat", "", "abc")
+	changed.AddBranch("main", "def", "def") // nothing moved
+	changed.AddWorktree(ActionAdded, "/wt/feat", "feat", "abc")
+	empty := New("sync", nil)
+	marker := New(OpUndo, nil)
+	marker.Undoes = changed.ID
+
+	for _, e := range []*Entry{changed, empty, marker, nil} {
+		if err := Append(dir, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != changed.ID || entries[1].ID != marker.ID {
+		t.Fatalf("Read() = %+v, want the change and the undo marker", entries)
+	}
+	if want := []BranchChange{{Name: "feat", After: "abc"}}; !reflect.DeepEqual(entries[0].Branches, want) {
+		t.Errorf("branches = %+v, want %+v", entries[0].Branches, want)
+	}
+	if LastUndoable(entries) != nil {
+		t.Errorf("LastUndoable() after undoing everything = %+v, want nil", LastUndoable(entries))
+	}
+
+	if err := os.WriteFile(Path(dir), []byte("{not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(dir); err == nil {
+		t.Error("Read() of a corrupt journal succeeded")
+	}
+}
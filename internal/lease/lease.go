@@ -0,0 +1,177 @@
+// Package lease hands out time-limited, exclusive claims on worktrees so that
+// automated agents do not pick up the same worktree. Leases live in
+// gwt/leases.json in the common git dir and are kept alive by heartbeats.
+package lease
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nachoal/gwt/internal/lock"
+)
+
+// Lease is an owner's claim on a worktree until ExpiresAt.
+type Lease struct {
+	Path        string    `json:"path"`
+	Branch      string    `json:"branch,omitempty"`
+	Owner       string    `json:"owner"`
+	TTL         string    `json:"ttl"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the lease ran out without a heartbeat.
+func (l Lease) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// Reason is the `git worktree lock` reason gwt uses for a lease. It leaves
+// out the expiry so that heartbeats do not have to re-lock.
+func (l Lease) Reason() string {
+	return "gwt lease: " + l.Owner
+}
+
+// Set holds the leases of one repository.
+type Set struct {
+	Leases []Lease `json:"leases"`
+}
+
+// Path returns the lease file location for a common git dir.
+func Path(commonGitDir string) string {
+	return filepath.Join(commonGitDir, "gwt", "leases.json")
+}
+
+// Load reads the leases of a repository. A missing file is an empty set.
+func Load(commonGitDir string) (*Set, error) {
+	s := &Set{Leases: []Lease{}}
+	data, err := os.ReadFile(Path(commonGitDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", Path(commonGitDir), err)
+	}
+	return s, nil
+}
+
+// Update loads the leases under an exclusive lock, applies fn and saves the
+// result. It has its own lock, so heartbeats never wait for a slow `gwt new`.
+func Update(commonGitDir string, fn func(*Set) error) error {
+	l, err := lock.Acquire(filepath.Join(commonGitDir, "gwt", "leases.lock"), "gwt lease update", lock.DefaultTimeout, nil)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	s, err := Load(commonGitDir)
+	if err != nil {
+		return err
+	}
+	if err := fn(s); err != nil {
+		return err
+	}
+	return s.save(commonGitDir)
+}
+
+// Find returns the lease on path, expired or not, or nil.
+func (s *Set) Find(path string) *Lease {
+	for i := range s.Leases {
+		if s.Leases[i].Path == path {
+			return &s.Leases[i]
+		}
+	}
+	return nil
+}
+
+// Active returns the unexpired lease on path, or nil.
+func (s *Set) Active(path string, now time.Time) *Lease {
+	if l := s.Find(path); l != nil && !l.Expired(now) {
+		return l
+	}
+	return nil
+}
+
+// Put adds or replaces the lease for l.Path.
+func (s *Set) Put(l Lease) {
+	if existing := s.Find(l.Path); existing != nil {
+		*existing = l
+		return
+	}
+	s.Leases = append(s.Leases, l)
+}
+
+// Remove drops the lease on path.
+func (s *Set) Remove(path string) {
+	kept := s.Leases[:0]
+	for _, l := range s.Leases {
+		if l.Path != path {
+			kept = append(kept, l)
+		}
+	}
+	s.Leases = kept
+}
+
+// New returns a lease on path for owner lasting ttl from now.
+func New(path, branch, owner string, ttl time.Duration) Lease {
+	now := time.Now().UTC()
+	return Lease{
+		Path:        path,
+		Branch:      branch,
+		Owner:       owner,
+		TTL:         ttl.String(),
+		AcquiredAt:  now,
+		HeartbeatAt: now,
+		ExpiresAt:   now.Add(ttl),
+	}
+}
+
+// Renew extends the lease by ttl from now.
+func (l *Lease) Renew(ttl time.Duration) {
+	now := time.Now().UTC()
+	l.TTL = ttl.String()
+	l.HeartbeatAt = now
+	l.ExpiresAt = now.Add(ttl)
+}
+
+// ErrLeased is returned when a worktree is leased by someone else.
+type ErrLeased struct {
+	Lease Lease
+}
+
+func (e *ErrLeased) Error() string {
+	return fmt.Sprintf("worktree %s is leased by %s until %s", e.Lease.Path, e.Lease.Owner, e.Lease.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+func (s *Set) save(commonGitDir string) error {
+	sort.Slice(s.Leases, func(i, j int) bool { return s.Leases[i].Path < s.Leases[j].Path })
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(Path(commonGitDir))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "leases.json.tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), Path(commonGitDir))
+}
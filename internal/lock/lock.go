@@ -0,0 +1,120 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTimeout is how long Acquire waits for another gwt process by default.
+const DefaultTimeout = 30 * time.Second
+
+const pollInterval = 100 * time.Millisecond
+
+// Lock is an advisory, cross-process lock held on a file. The lock is released
+// by the kernel if the holding process dies, so it never goes stale.
+type Lock struct {
+	f *os.File
+}
+
+// Holder describes the process holding a lock.
+type Holder struct {
+	PID     int       `json:"pid"`
+	Command string    `json:"command"`
+	Since   time.Time `json:"since"`
+}
+
+func (h *Holder) String() string {
+	if h == nil || h.PID == 0 {
+		return "another gwt process"
+	}
+	return fmt.Sprintf("pid %d running `%s` (since %s)", h.PID, h.Command, h.Since.Local().Format("15:04:05"))
+}
+
+// TimeoutError is returned when a lock could not be acquired in time.
+type TimeoutError struct {
+	Path    string
+	Holder  *Holder
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("repository is locked by %s; gave up after %s (lock file: %s)", e.Holder, e.Timeout, e.Path)
+}
+
+// RepoPath returns the per-repository lock file inside a common git dir.
+func RepoPath(commonGitDir string) string {
+	return filepath.Join(commonGitDir, "gwt", "lock")
+}
+
+// Acquire takes an exclusive lock on path, waiting up to timeout for the
+// current holder to finish. command describes the caller and is shown to
+// other processes waiting on the lock. If waiting is non-nil it is called once
+// when the lock is busy, before Acquire starts to wait.
+func Acquire(path, command string, timeout time.Duration, waiting func(*Holder)) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	notified := false
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if ok {
+			break
+		}
+		if !notified && waiting != nil {
+			waiting(readHolder(path))
+			notified = true
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, &TimeoutError{Path: path, Holder: readHolder(path), Timeout: timeout}
+		}
+		time.Sleep(pollInterval)
+	}
+
+	// Record who holds the lock so that waiters can tell the user.
+	holder := Holder{PID: os.Getpid(), Command: command, Since: time.Now()}
+	if data, err := json.Marshal(holder); err == nil {
+		_ = f.Truncate(0)
+		_, _ = f.WriteAt(data, 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release clears the holder record and releases the lock.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	_ = l.f.Truncate(0)
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+func readHolder(path string) *Holder {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var h Holder
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil
+	}
+	return &h
+}
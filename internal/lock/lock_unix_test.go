@@ -0,0 +1,101 @@
+//go:build unix
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireTimeout(t *testing.T) {
+	path := RepoPath(t.TempDir())
+	held, err := Acquire(path, "gwt new feat", time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var waitedOn *Holder
+	calls := 0
+	start := time.Now()
+	_, err = Acquire(path, "gwt done feat", 300*time.Millisecond, func(h *Holder) {
+		waitedOn = h
+		calls++
+	})
+	elapsed := time.Since(start)
+
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("second Acquire error = %v, want a *TimeoutError", err)
+	}
+	if elapsed < 300*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("gave up after %s, want about 300ms", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("waiting called %d times, want once", calls)
+	}
+	for _, h := range []*Holder{waitedOn, timeout.Holder} {
+		if h == nil || h.PID != os.Getpid() || h.Command != "gwt new feat" {
+			t.Errorf("holder = %+v, want this process running 'gwt new feat'", h)
+		}
+	}
+	if msg := err.Error(); !strings.Contains(msg, "gwt new feat") || !strings.Contains(msg, "300ms") || !strings.Contains(msg, path) {
+		t.Errorf("error %q should name the holder, the timeout and the lock file", msg)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Acquire(path, "gwt done feat", 0, nil)
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	if err := again.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gwt", "lock")
+	held, err := Acquire(path, "gwt sync", time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = held.Release()
+	}()
+
+	waited := false
+	l, err := Acquire(path, "gwt new feat", 5*time.Second, func(*Holder) { waited = true })
+	if err != nil {
+		t.Fatalf("Acquire while the holder releases: %v", err)
+	}
+	defer l.Release()
+	if !waited {
+		t.Error("waiting was not called although the lock was busy")
+	}
+	if h := readHolder(path); h == nil || h.Command != "gwt new feat" {
+		t.Errorf("holder record = %+v, want the new holder", h)
+	}
+}
+
+func TestReleaseTwice(t *testing.T) {
+	l, err := Acquire(RepoPath(t.TempDir()), "gwt new", time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Release(); err != nil {
+		t.Errorf("second Release: %v", err)
+	}
+	var nilLock *Lock
+	if err := nilLock.Release(); err != nil {
+		t.Errorf("Release on nil: %v", err)
+	}
+}
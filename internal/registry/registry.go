@@ -0,0 +1,194 @@
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nachoal/gwt/internal/lock"
+)
+
+// Entry records a worktree gwt created under the root, so that lookups do not
+// depend on guessing the directory layout produced by settings.path_template.
+type Entry struct {
+	Path      string    `json:"path"`
+	Project   string    `json:"project"`
+	Branch    string    `json:"branch"`
+	CommonDir string    `json:"common_dir"`
+	CreatedAt time.Time `json:"created_at"`
+	// PortOffset and Ports are the ports reserved for the worktree (see the
+	// ports: config); they are released when the entry is removed.
+	PortOffset int            `json:"port_offset,omitempty"`
+	Ports      map[string]int `json:"ports,omitempty"`
+}
+
+// Registry is the root-level index of gwt-managed worktrees.
+type Registry struct {
+	Worktrees []Entry `json:"worktrees"`
+}
+
+// Dir returns the directory under root where gwt keeps root-level state.
+func Dir(root string) string {
+	return filepath.Join(root, ".gwt")
+}
+
+// Path returns the registry file location for root.
+func Path(root string) string {
+	return filepath.Join(Dir(root), "registry.json")
+}
+
+// Load reads the registry under root. A missing registry is empty.
+// Entries whose directory no longer exists are dropped.
+func Load(root string) (*Registry, error) {
+	r := &Registry{Worktrees: []Entry{}}
+	data, err := os.ReadFile(Path(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+
+	kept := r.Worktrees[:0]
+	for _, e := range r.Worktrees {
+		if _, err := os.Stat(e.Path); err == nil {
+			kept = append(kept, e)
+		}
+	}
+	r.Worktrees = kept
+	return r, nil
+}
+
+// Stale returns the entries under root whose directory no longer exists.
+// Load drops them, so the next Update removes them from the file.
+func Stale(root string) ([]Entry, error) {
+	data, err := os.ReadFile(Path(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var r Registry
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	var stale []Entry
+	for _, e := range r.Worktrees {
+		if _, err := os.Stat(e.Path); os.IsNotExist(err) {
+			stale = append(stale, e)
+		}
+	}
+	return stale, nil
+}
+
+// Update loads the registry under an exclusive root-level lock, applies fn and
+// saves the result.
+func Update(root string, fn func(*Registry) error) error {
+	l, err := lock.Acquire(filepath.Join(Dir(root), "lock"), "gwt registry update", lock.DefaultTimeout, nil)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	r, err := Load(root)
+	if err != nil {
+		return err
+	}
+	if err := fn(r); err != nil {
+		return err
+	}
+	return r.save(root)
+}
+
+// Register records a worktree under root, replacing any entry for the same path.
+func Register(root string, e Entry) error {
+	return Update(root, func(r *Registry) error {
+		r.Put(e)
+		return nil
+	})
+}
+
+// Put adds or replaces the entry for e.Path. Creation time and reserved ports
+// carry over from an existing entry unless e sets them.
+func (r *Registry) Put(e Entry) {
+	if old := r.Find(e.Path); old != nil {
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = old.CreatedAt
+		}
+		if e.Ports == nil {
+			e.PortOffset, e.Ports = old.PortOffset, old.Ports
+		}
+		*old = e
+		return
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	r.Worktrees = append(r.Worktrees, e)
+}
+
+// Remove drops the entry for path, releasing its ports.
+func (r *Registry) Remove(path string) {
+	kept := r.Worktrees[:0]
+	for _, e := range r.Worktrees {
+		if e.Path != path {
+			kept = append(kept, e)
+		}
+	}
+	r.Worktrees = kept
+}
+
+// ReservedPorts returns every port reserved by an entry other than path.
+func (r *Registry) ReservedPorts(path string) map[int]bool {
+	reserved := map[int]bool{}
+	for _, e := range r.Worktrees {
+		if e.Path == path {
+			continue
+		}
+		for _, p := range e.Ports {
+			reserved[p] = true
+		}
+	}
+	return reserved
+}
+
+// Find returns the entry for path, or nil.
+func (r *Registry) Find(path string) *Entry {
+	for i := range r.Worktrees {
+		if r.Worktrees[i].Path == path {
+			return &r.Worktrees[i]
+		}
+	}
+	return nil
+}
+
+func (r *Registry) save(root string) error {
+	sort.Slice(r.Worktrees, func(i, j int) bool { return r.Worktrees[i].Path < r.Worktrees[j].Path })
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(root), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(Dir(root), "registry.json.tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), Path(root))
+}
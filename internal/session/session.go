@@ -0,0 +1,241 @@
+// Package session opens terminal multiplexer sessions for worktrees: a tmux
+// session, or a zellij tab (inside zellij) or session (outside it).
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	Tmux   = "tmux"
+	Zellij = "zellij"
+)
+
+// Session identifies the session gwt opened for a worktree.
+type Session struct {
+	Multiplexer string `json:"multiplexer"`
+	Name        string `json:"name"`
+	// Parent is the zellij session holding Name as a tab, or "" when Name is
+	// a session of its own.
+	Parent string `json:"parent,omitempty"`
+}
+
+// Window is one window (tmux) or pane (zellij) of a new session; Command is
+// typed into its shell, and an empty Command leaves a plain shell.
+type Window struct {
+	Name    string
+	Command string
+}
+
+// New returns the session that Open would use for name under multiplexer.
+// Inside zellij, worktrees become tabs of the current session.
+func New(multiplexer, name string) (Session, error) {
+	s := Session{Multiplexer: multiplexer, Name: name}
+	switch multiplexer {
+	case Tmux:
+	case Zellij:
+		s.Parent = os.Getenv("ZELLIJ_SESSION_NAME")
+	default:
+		return s, fmt.Errorf("unknown multiplexer %q (use tmux or zellij)", multiplexer)
+	}
+	if _, err := exec.LookPath(multiplexer); err != nil {
+		return s, fmt.Errorf("%s not found in PATH", multiplexer)
+	}
+	return s, nil
+}
+
+// Open creates the session in dir with windows unless it is already running,
+// then attaches to it (or switches to it from inside the multiplexer). When
+// attaching from outside, it returns once the user detaches.
+func (s Session) Open(dir string, windows []Window) error {
+	if s.Multiplexer == Zellij {
+		return s.openZellij(dir, windows)
+	}
+	if !s.Alive() {
+		if err := s.createTmux(dir, windows); err != nil {
+			return err
+		}
+	}
+	if os.Getenv("TMUX") != "" {
+		return run("tmux", "switch-client", "-t", "="+s.Name)
+	}
+	return attach("", "tmux", "attach-session", "-t", "="+s.Name)
+}
+
+// Alive reports whether the session (or tab) is running.
+func (s Session) Alive() bool {
+	switch s.Multiplexer {
+	case Tmux:
+		return exec.Command("tmux", "has-session", "-t", "="+s.Name).Run() == nil
+	case Zellij:
+		if s.Parent != "" {
+			tabs, err := output("zellij", "--session", s.Parent, "action", "query-tab-names")
+			return err == nil && containsLine(tabs, s.Name)
+		}
+		return zellijSessionRunning(s.Name)
+	}
+	return false
+}
+
+// Kill ends the session, or closes the zellij tab. A session that is not
+// running is not an error.
+func (s Session) Kill() error {
+	if !s.Alive() {
+		return nil
+	}
+	switch s.Multiplexer {
+	case Tmux:
+		return run("tmux", "kill-session", "-t", "="+s.Name)
+	case Zellij:
+		if s.Parent != "" {
+			if err := run("zellij", "--session", s.Parent, "action", "go-to-tab-name", s.Name); err != nil {
+				return err
+			}
+			return run("zellij", "--session", s.Parent, "action", "close-tab")
+		}
+		return run("zellij", "kill-session", s.Name)
+	}
+	return nil
+}
+
+func (s Session) createTmux(dir string, windows []Window) error {
+	if len(windows) == 0 {
+		windows = []Window{{}}
+	}
+	ids := make([]string, 0, len(windows))
+	for i, w := range windows {
+		args := []string{"new-window", "-d", "-t", "=" + s.Name + ":", "-c", dir}
+		if i == 0 {
+			args = []string{"new-session", "-d", "-s", s.Name, "-c", dir}
+		}
+		if w.Name != "" {
+			args = append(args, "-n", w.Name)
+		}
+		id, err := output("tmux", append(args, "-P", "-F", "#{window_id}")...)
+		if err != nil {
+			return err
+		}
+		id = strings.TrimSpace(id)
+		ids = append(ids, id)
+		if w.Command != "" {
+			// Typed rather than passed as the window command, so the shell
+			// stays open when the command exits.
+			if err := run("tmux", "send-keys", "-t", id, w.Command, "Enter"); err != nil {
+				return err
+			}
+		}
+	}
+	return run("tmux", "select-window", "-t", ids[0])
+}
+
+func (s Session) openZellij(dir string, windows []Window) error {
+	layout := ""
+	if len(windows) > 0 && !s.Alive() {
+		f, err := os.CreateTemp("", "gwt-layout-*.kdl")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(zellijLayout(dir, windows))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		layout = f.Name()
+	}
+
+	if s.Parent != "" {
+		if s.Alive() {
+			return run("zellij", "action", "go-to-tab-name", s.Name)
+		}
+		args := []string{"action", "new-tab", "--name", s.Name, "--cwd", dir}
+		if layout != "" {
+			args = append(args, "--layout", layout)
+		}
+		return run("zellij", args...)
+	}
+	if layout != "" {
+		return attach(dir, "zellij", "--session", s.Name, "--layout", layout)
+	}
+	return attach(dir, "zellij", "attach", "--create", s.Name)
+}
+
+// zellijLayout lays windows out as panes, each running its command in a shell
+// that stays open afterwards.
+func zellijLayout(dir string, windows []Window) string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "layout {\n    cwd %s\n", strconv.Quote(dir))
+	for _, w := range windows {
+		fmt.Fprintf(&b, "    pane name=%s", strconv.Quote(w.Name))
+		if w.Command != "" {
+			fmt.Fprintf(&b, " command=%s {\n        args \"-c\" %s\n    }\n", strconv.Quote(shell), strconv.Quote(w.Command+"; exec "+shell))
+		} else {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func zellijSessionRunning(name string) bool {
+	out, err := output("zellij", "list-sessions", "--no-formatting")
+	if err != nil {
+		return false
+	}
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) > 0 && fields[0] == name && !strings.Contains(sc.Text(), "EXITED") {
+			return true
+		}
+	}
+	return false
+}
+
+// attach runs an interactive multiplexer client on the terminal. stdout may
+// be captured by the shell integration, so the client then writes to stderr.
+func attach(dir, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		cmd.Stdout = os.Stderr
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func run(name string, args ...string) error {
+	_, err := output(name, args...)
+	return err
+}
+
+func output(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+func containsLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Package shellwords turns configured command lines into argument vectors the
+// way a POSIX shell splits words, without running a shell.
+package shellwords
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Split splits line into words on unquoted whitespace. Single quotes keep
+// their contents literally; inside double quotes a backslash only escapes
+// '"', '\', '$' and '`'; elsewhere it escapes any character. Variables, globs
+// and other expansions are not performed.
+func Split(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\'':
+			inWord = true
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated ' in %q", line)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i = end
+		case r == '"':
+			inWord = true
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated \" in %q", line)
+				}
+				if runes[i] == '"' {
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+		case r == '\\':
+			inWord = true
+			if i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			}
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Expand renders line as a text/template with data, e.g. "code {{ .Path }}",
+// and splits the result into words. Values of data are not split or unquoted:
+// each ends up inside the word it was rendered into, whatever it contains.
+// used reports the keys whose value appears in the result.
+func Expand(line string, data map[string]string) (argv []string, used map[string]bool, err error) {
+	t, err := template.New("command").Option("missingkey=error").Parse(line)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Render placeholders that no shell syntax touches, split, and only then
+	// put the values in.
+	keys := make([]string, 0, len(data))
+	placeholders := make(map[string]string, len(data))
+	for k := range data {
+		keys = append(keys, k)
+		placeholders[k] = "\x00" + k + "\x00"
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	if err := t.Execute(&b, placeholders); err != nil {
+		return nil, nil, err
+	}
+	words, err := Split(b.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	used = make(map[string]bool)
+	for _, word := range words {
+		for _, k := range keys {
+			if strings.Contains(word, placeholders[k]) {
+				word = strings.ReplaceAll(word, placeholders[k], data[k])
+				used[k] = true
+			}
+		}
+		argv = append(argv, word)
+	}
+	return argv, used, nil
+}
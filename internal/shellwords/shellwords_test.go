@@ -0,0 +1,84 @@
+package shellwords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{line: "", want: nil},
+		{line: "  code  --wait\t-n ", want: []string{"code", "--wait", "-n"}},
+		{line: `agent --title "my agent"`, want: []string{"agent", "--title", "my agent"}},
+		{line: `agent --title 'my "agent"'`, want: []string{"agent", "--title", `my "agent"`}},
+		{line: `echo "a \"b\" \$c \n"`, want: []string{"echo", `a "b" $c \n`}},
+		{line: `echo a\ b \'c`, want: []string{"echo", "a b", "'c"}},
+		{line: `echo ""`, want: []string{"echo", ""}},
+		{line: `echo pre"mid dle"post`, want: []string{"echo", "premid dlepost"}},
+		{line: `echo 'open`, wantErr: true},
+		{line: `echo "open`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Split(%q) error = %v, wantErr %t", tt.line, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	data := map[string]string{"Path": "/wt/my repo/feat", "Prompt": `fix "it" & it's done`}
+	tests := []struct {
+		line     string
+		want     []string
+		wantUsed []string
+		wantErr  bool
+	}{
+		{line: "code", want: []string{"code"}},
+		{line: "code {{.Path}}", want: []string{"code", "/wt/my repo/feat"}, wantUsed: []string{"Path"}},
+		{line: "code {{ .Path }}", want: []string{"code", "/wt/my repo/feat"}, wantUsed: []string{"Path"}},
+		{line: "code --goto={{ .Path }}:1", want: []string{"code", "--goto=/wt/my repo/feat:1"}, wantUsed: []string{"Path"}},
+		{
+			line:     `agent --title "my agent" -p {{ .Prompt }}`,
+			want:     []string{"agent", "--title", "my agent", "-p", `fix "it" & it's done`},
+			wantUsed: []string{"Prompt"},
+		},
+		{
+			line:     `agent '{{.Prompt}} in {{.Path}}'`,
+			want:     []string{"agent", `fix "it" & it's done in /wt/my repo/feat`},
+			wantUsed: []string{"Path", "Prompt"},
+		},
+		{line: `{{if .Prompt}}agent -p {{.Prompt}}{{else}}agent{{end}}`, want: []string{"agent", "-p", `fix "it" & it's done`}, wantUsed: []string{"Prompt"}},
+		{line: "code {{.Nope}}", wantErr: true},
+		{line: "code {{ .Path", wantErr: true},
+		{line: `code "{{.Path}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, used, err := Expand(tt.line, data)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Expand(%q) error = %v, wantErr %t", tt.line, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expand(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+		wantUsed := map[string]bool{}
+		for _, k := range tt.wantUsed {
+			wantUsed[k] = true
+		}
+		if !reflect.DeepEqual(used, wantUsed) {
+			t.Errorf("Expand(%q) used %v, want %v", tt.line, used, wantUsed)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package spawn records groups of worktrees created by `gwt spawn`, each
+// running a detached agent on the same task, and tracks their progress.
+package spawn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Member states reported by Member.State.
+const (
+	StatePending = "pending"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+	// StateLost means the agent is gone without recording an exit status
+	// (e.g. the machine rebooted or it was killed with SIGKILL).
+	StateLost = "lost"
+)
+
+// Group is one `gwt spawn` run.
+type Group struct {
+	Task      string    `json:"task"`
+	Base      string    `json:"base"`
+	BaseSHA   string    `json:"base_sha"`
+	Agent     string    `json:"agent"`
+	Prompt    string    `json:"prompt,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Members   []Member  `json:"members"`
+}
+
+// Member is one worktree of a group and its agent process.
+type Member struct {
+	Index  int    `json:"index"`
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+	// PID is the agent's process, 0 if it was not started.
+	PID       int       `json:"pid,omitempty"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+	// Log receives the agent's output; ExitFile its exit status when it ends.
+	Log      string `json:"log"`
+	ExitFile string `json:"exit_file"`
+	// Error is why the worktree could not be provisioned or the agent started.
+	Error string `json:"error,omitempty"`
+}
+
+// Dir returns the directory holding spawn groups for a common git dir.
+func Dir(commonGitDir string) string {
+	return filepath.Join(commonGitDir, "gwt", "spawn")
+}
+
+// fileName maps a task (which may contain '/') to a file name.
+func fileName(task string) string {
+	return strings.ReplaceAll(task, "/", "%2F")
+}
+
+// Path returns the group file for task.
+func Path(commonGitDir, task string) string {
+	return filepath.Join(Dir(commonGitDir), fileName(task)+".json")
+}
+
+// LogDir returns the directory for the agent logs of task.
+func LogDir(commonGitDir, task string) string {
+	return filepath.Join(Dir(commonGitDir), fileName(task))
+}
+
+// Save writes the group atomically.
+func Save(commonGitDir string, g *Group) error {
+	file := Path(commonGitDir, g.Task)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Load reads the group for task.
+func Load(commonGitDir, task string) (*Group, error) {
+	data, err := os.ReadFile(Path(commonGitDir, task))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no spawn group '%s'", task)
+		}
+		return nil, err
+	}
+	var g Group
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("invalid spawn group '%s': %w", task, err)
+	}
+	return &g, nil
+}
+
+// List returns all recorded groups, oldest first.
+func List(commonGitDir string) ([]*Group, error) {
+	files, err := filepath.Glob(filepath.Join(Dir(commonGitDir), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var groups []*Group
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var g Group
+		if err := json.Unmarshal(data, &g); err != nil {
+			return nil, fmt.Errorf("invalid spawn group %s: %w", f, err)
+		}
+		groups = append(groups, &g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].CreatedAt.Before(groups[j].CreatedAt) })
+	return groups, nil
+}
+
+// State reports the member's progress and, once finished, the agent's exit code.
+func (m Member) State() (string, int) {
+	if data, err := os.ReadFile(m.ExitFile); err == nil {
+		code, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && code == 0 {
+			return StateDone, 0
+		}
+		return StateFailed, code
+	}
+	switch {
+	case m.Error != "":
+		return StateFailed, -1
+	case m.PID == 0:
+		return StatePending, 0
+	case processAlive(m.PID):
+		return StateRunning, 0
+	}
+	return StateLost, -1
+}
+
+// Diff summarizes what an agent changed relative to the group's base commit,
+// committed or not.
+type Diff struct {
+	// Files are the changed tracked and untracked files, sorted.
+	Files      []string `json:"files"`
+	Insertions int      `json:"insertions"`
+	Deletions  int      `json:"deletions"`
+	Untracked  int      `json:"untracked"`
+	Commits    int      `json:"commits"`
+}
+
+// Diff compares the member's worktree (including uncommitted and untracked
+// files) with baseSHA.
+func (m Member) Diff(baseSHA string) (Diff, error) {
+	var d Diff
+	out, err := exec.Command("git", "-C", m.Path, "diff", "--numstat", baseSHA).Output()
+	if err != nil {
+		return d, fmt.Errorf("git diff failed in %s: %w", m.Path, err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files report "-" for both counts.
+		add, _ := strconv.Atoi(fields[0])
+		del, _ := strconv.Atoi(fields[1])
+		d.Insertions += add
+		d.Deletions += del
+		d.Files = append(d.Files, fields[2])
+	}
+	out, err = exec.Command("git", "-C", m.Path, "ls-files", "--others", "--exclude-standard").Output()
+	if err != nil {
+		return d, fmt.Errorf("git ls-files failed in %s: %w", m.Path, err)
+	}
+	for _, f := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if f != "" {
+			d.Files = append(d.Files, f)
+			d.Untracked++
+		}
+	}
+	sort.Strings(d.Files)
+	out, err = exec.Command("git", "-C", m.Path, "rev-list", "--count", baseSHA+"..HEAD").Output()
+	if err == nil {
+		d.Commits, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+	}
+	return d, nil
+}
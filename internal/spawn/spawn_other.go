@@ -0,0 +1,17 @@
+//go:build !unix
+
+package spawn
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Start needs detached sessions and a POSIX shell, which only unix has.
+func (m *Member) Start(argv []string, dir string, env []string) error {
+	return fmt.Errorf("gwt spawn is not supported on %s", runtime.GOOS)
+}
+
+func processAlive(pid int) bool {
+	return false
+}
@@ -0,0 +1,54 @@
+//go:build unix
+
+package spawn
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Start launches argv in dir as a detached process (its own session, stdin
+// from /dev/null, output appended to m.Log) that survives gwt exiting, and
+// records its pid. A shell wrapper writes the exit status to m.ExitFile.
+func (m *Member) Start(argv []string, dir string, env []string) error {
+	if err := os.MkdirAll(filepath.Dir(m.Log), 0o755); err != nil {
+		return err
+	}
+	_ = os.Remove(m.ExitFile)
+	logFile, err := os.OpenFile(m.Log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	script := `"$@"; status=$?; echo $status > "$GWT_SPAWN_EXIT_FILE"; exit $status`
+	cmd := exec.Command("sh", append([]string{"-c", script, "sh"}, argv...)...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), env...), "GWT_SPAWN_EXIT_FILE="+m.ExitFile)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = devNull, logFile, logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	m.PID = cmd.Process.Pid
+	m.StartedAt = time.Now().UTC()
+	// Reap it if gwt is still around when it ends; otherwise init does.
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -19,8 +19,7 @@ type step struct {
 }
 
 type createModel struct {
-	branchName     string
-	fromBranch     string
+	spec           worktree.CreateSpec
 	steps          []step
 	currentStep    int
 	spinner        spinner.Model
@@ -28,6 +27,7 @@ type createModel struct {
 	err            error
 	loadedConfig   *config.Config
 	worktreePath   string
+	projectName    string
 	currentCommand string
 	setupCommands  []string
 	setupHistory   []setupCommandResult
@@ -51,14 +51,13 @@ var (
 	stepStyle = uiRenderer.NewStyle().PaddingLeft(2)
 )
 
-func NewCreateModel(branchName, fromBranch string) createModel {
+func NewCreateModel(spec worktree.CreateSpec) createModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = uiRenderer.NewStyle().Foreground(lipgloss.Color("205"))
 
 	return createModel{
-		branchName: branchName,
-		fromBranch: fromBranch,
+		spec: spec,
 		steps: []step{
 			{name: "Loading configuration", status: "running"},
 			{name: "Creating worktree", status: "pending"},
@@ -189,9 +188,13 @@ func (m createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Store data from completed steps
 		if msg.worktreePath != "" {
 			m.worktreePath = msg.worktreePath
+			m.projectName = msg.projectName
 		}
 		if msg.config != nil {
 			m.loadedConfig = msg.config
+			if msg.config.Compose != nil {
+				m.steps[2].name = "Copying files and starting services"
+			}
 		}
 
 		m.steps[m.currentStep].status = "done"
@@ -280,6 +283,7 @@ func (m createModel) View() string {
 type stepCompleteMsg struct {
 	err          error
 	worktreePath string
+	projectName  string
 	config       *config.Config
 }
 
@@ -325,21 +329,26 @@ func (m createModel) runNextStep() tea.Cmd {
 			return stepCompleteMsg{config: cfg}
 
 		case 1: // Create worktree
-			projectName, err := worktree.GetProjectName()
+			cfg, err := m.getConfig()
 			if err != nil {
 				return stepCompleteMsg{err: err}
 			}
 
-			cfg, err := m.getConfig()
+			projectName, err := worktree.ResolveProjectName(cfg.Settings.Root, cfg.Settings.Project)
+			if err != nil {
+				return stepCompleteMsg{err: err}
+			}
+			targetPath, err := worktree.RenderWorktreePath(cfg.Settings.PathTemplate, cfg.Settings.Root, projectName, m.spec.Name)
 			if err != nil {
 				return stepCompleteMsg{err: err}
 			}
-			targetPath := worktree.GetWorktreePath(cfg.Settings.Root, projectName, m.branchName)
 
-			if err := worktree.Create(m.branchName, m.fromBranch, targetPath); err != nil {
+			if err := worktree.CreateAt(m.spec, targetPath); err != nil {
 				return stepCompleteMsg{err: err}
 			}
-			return stepCompleteMsg{worktreePath: targetPath}
+			// Registry bookkeeping is best effort; the worktree itself is usable.
+			_ = worktree.Register(cfg.Settings.Root, projectName, m.spec.Name, targetPath)
+			return stepCompleteMsg{worktreePath: targetPath, projectName: projectName}
 
 		case 2: // Copy files
 			if m.worktreePath == "" {
@@ -360,6 +369,17 @@ func (m createModel) runNextStep() tea.Cmd {
 			if err := worktree.CopyFiles(mainPath, m.worktreePath, cfg.Copy); err != nil {
 				return stepCompleteMsg{err: err}
 			}
+			ports, err := worktree.AssignPorts(cfg.Settings.Root, m.worktreePath, cfg.Ports)
+			if err != nil {
+				return stepCompleteMsg{err: err}
+			}
+			data := worktree.NewTemplateData(m.spec, m.projectName, m.worktreePath, mainPath, ports)
+			if err := worktree.RenderTemplates(mainPath, m.worktreePath, cfg.Templates, data); err != nil {
+				return stepCompleteMsg{err: err}
+			}
+			if _, err := worktree.StartCompose(cfg.Compose, m.projectName, m.spec.Name, m.worktreePath, ports); err != nil {
+				return stepCompleteMsg{err: err}
+			}
 			return stepCompleteMsg{}
 
 		case 3: // Run setup commands
@@ -379,6 +399,12 @@ func (m createModel) runNextStep() tea.Cmd {
 	}
 }
 
+// WorktreePath returns the path of the created worktree, or "" if creation
+// did not get that far.
+func (m createModel) WorktreePath() string {
+	return m.worktreePath
+}
+
 func (m createModel) getConfig() (*config.Config, error) {
 	if m.loadedConfig != nil {
 		return m.loadedConfig, nil
@@ -7,17 +7,29 @@ import (
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/editor"
+	"github.com/nachoal/gwt/internal/forge"
+	"github.com/nachoal/gwt/internal/journal"
+	"github.com/nachoal/gwt/internal/lock"
 	"github.com/nachoal/gwt/internal/worktree"
 )
 
 type listModel struct {
-	table         table.Model
-	worktrees     []worktree.Worktree
+	table     table.Model
+	worktrees []worktree.Worktree
+	metadata  map[string]*worktree.Metadata
+	live      map[string]bool
+	prs       map[string]*forge.PullRequest
+	tags      []string
+	provider  forge.Provider
+	// root is where deleted worktrees release their registry entry.
+	root          string
 	err           error
 	quitting      bool
 	selectedPath  string
 	confirmDelete bool
-	deleteTarget  string
+	deleteTarget  worktree.Worktree
 }
 
 var (
@@ -30,12 +42,22 @@ var (
 			Bold(true)
 )
 
-func NewListModel() listModel {
+// NewListModel lists the repository's worktrees, keeping only those that carry
+// every tag in tags. With a provider, a PR column shows each branch's pull
+// request. Deleting a worktree releases its entry in the registry under root.
+func NewListModel(root string, tags []string, provider forge.Provider) listModel {
 	columns := []table.Column{
 		{Title: "Branch", Width: 30},
 		{Title: "Path", Width: 50},
 		{Title: "Status", Width: 15},
 	}
+	if provider != nil {
+		columns = append(columns, table.Column{Title: "PR", Width: 14})
+	}
+	columns = append(columns,
+		table.Column{Title: "Session", Width: 10},
+		table.Column{Title: "Notes", Width: 40},
+	)
 
 	t := table.New(
 		table.WithColumns(columns),
@@ -49,7 +71,10 @@ func NewListModel() listModel {
 	t.SetStyles(s)
 
 	return listModel{
-		table: t,
+		table:    t,
+		tags:     tags,
+		provider: provider,
+		root:     root,
 	}
 }
 
@@ -68,50 +93,42 @@ func (m listModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.confirmDelete {
 				return m, nil
 			}
-			selectedRow := m.table.SelectedRow()
-			if len(selectedRow) == 0 {
+			wt, ok := m.selected()
+			if !ok {
 				return m, nil
 			}
-			selectedBranch := selectedRow[0]
-			for _, wt := range m.worktrees {
-				if wt.Branch == selectedBranch {
-					m.selectedPath = wt.Path
-					m.quitting = true
-					return m, tea.Quit
-				}
+			m.selectedPath = wt.Path
+			m.quitting = true
+			return m, tea.Quit
+		case "o":
+			if m.confirmDelete {
+				return m, nil
 			}
-			return m, nil
+			wt, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			return m, m.openWorktree(wt.Path)
 		case "d":
 			if m.confirmDelete {
 				return m, nil // Already in confirm mode
 			}
 			// Get selected worktree
-			selectedRow := m.table.SelectedRow()
-			if len(selectedRow) > 0 {
+			if wt, ok := m.selected(); ok {
 				m.confirmDelete = true
-				m.deleteTarget = selectedRow[0] // Branch name
+				m.deleteTarget = wt
 			}
 			return m, nil
 		case "y":
-			if m.confirmDelete && m.deleteTarget != "" {
-				// Find the worktree path
-				var targetPath string
-				for _, wt := range m.worktrees {
-					if wt.Branch == m.deleteTarget {
-						targetPath = wt.Path
-						break
-					}
-				}
-				if targetPath != "" {
-					m.confirmDelete = false
-					return m, m.deleteWorktree(targetPath, m.deleteTarget)
-				}
+			if m.confirmDelete && m.deleteTarget.Path != "" {
+				m.confirmDelete = false
+				return m, m.deleteWorktree(m.deleteTarget)
 			}
 			return m, nil
 		case "n":
 			if m.confirmDelete {
 				m.confirmDelete = false
-				m.deleteTarget = ""
+				m.deleteTarget = worktree.Worktree{}
 			}
 			return m, nil
 		}
@@ -122,11 +139,20 @@ func (m listModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.worktrees = msg.worktrees
+		m.metadata = msg.metadata
+		m.live = msg.live
+		m.prs = msg.prs
 
 		rows := []table.Row{}
 		for _, wt := range m.worktrees {
 			status := "Clean"
 			// TODO: Check git status
+			switch {
+			case wt.Prunable:
+				status = "Prunable"
+			case wt.Locked:
+				status = "Locked"
+			}
 
 			// Make paths relative for display
 			path := wt.Path
@@ -137,13 +163,32 @@ func (m listModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-			rows = append(rows, table.Row{wt.Branch, path, status})
+			live := ""
+			if m.live[wt.Path] {
+				live = "● " + m.metadata[wt.Path].Session.Multiplexer
+			}
+
+			branch := wt.Branch
+			if wt.Detached {
+				branch = "(detached)"
+			}
+			row := table.Row{branch, path, status}
+			if m.provider != nil {
+				row = append(row, m.prs[wt.Path].Label())
+			}
+			rows = append(rows, append(row, live, m.metadata[wt.Path].Label()))
 		}
 		m.table.SetRows(rows)
 		return m, nil
 
+	case editorClosedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		return m, nil
+
 	case worktreeDeletedMsg:
-		m.deleteTarget = ""
+		m.deleteTarget = worktree.Worktree{}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
@@ -174,42 +219,108 @@ func (m listModel) View() string {
 			s += "\n" + uiRenderer.NewStyle().
 				Bold(true).
 				Foreground(lipgloss.Color("196")).
-				Render("⚠️  Delete worktree '"+m.deleteTarget+"'?") + "\n"
+				Render("⚠️  Delete worktree '"+m.deleteTarget.Name()+"'?") + "\n"
 			s += infoStyle.Render("y: Yes • n: No")
 		} else {
-			s += infoStyle.Render("↑/↓: Navigate • Enter: Switch (shell integration for auto-cd) • d: Delete • q: Quit")
+			s += infoStyle.Render("↑/↓: Navigate • Enter: Switch (shell integration for auto-cd) • o: Open in editor • d: Delete • q: Quit")
 		}
 	}
 
 	return s
 }
 
+// selected returns the worktree under the cursor.
+func (m listModel) selected() (worktree.Worktree, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.worktrees) {
+		return worktree.Worktree{}, false
+	}
+	return m.worktrees[i], true
+}
+
 func (m listModel) SelectedPath() string {
 	return m.selectedPath
 }
 
 type worktreesLoadedMsg struct {
 	worktrees []worktree.Worktree
-	err       error
+	metadata  map[string]*worktree.Metadata
+	// live marks worktrees whose tmux/zellij session is running.
+	live map[string]bool
+	// prs holds the pull request of each worktree's branch, when a forge is
+	// configured.
+	prs map[string]*forge.PullRequest
+	err error
 }
 
 func (m listModel) loadWorktrees() tea.Msg {
 	worktrees, err := worktree.List()
+	if err != nil {
+		return worktreesLoadedMsg{err: err}
+	}
+	metadata := make(map[string]*worktree.Metadata, len(worktrees))
+	live := make(map[string]bool)
+	prs := make(map[string]*forge.PullRequest)
+	kept := worktrees[:0]
+	for _, wt := range worktrees {
+		meta, _ := worktree.ReadMetadata(wt.Path)
+		if !meta.HasTags(m.tags) {
+			continue
+		}
+		metadata[wt.Path] = meta
+		if meta != nil && meta.Session != nil && meta.Session.Alive() {
+			live[wt.Path] = true
+		}
+		if m.provider != nil && wt.Branch != "" {
+			// Lookup failures just leave the column empty; stderr belongs to the TUI.
+			prs[wt.Path], _ = m.provider.PullRequest(wt.Branch)
+		}
+		kept = append(kept, wt)
+	}
 	return worktreesLoadedMsg{
-		worktrees: worktrees,
-		err:       err,
+		worktrees: kept,
+		metadata:  metadata,
+		live:      live,
+		prs:       prs,
 	}
 }
 
+type editorClosedMsg struct {
+	err error
+}
+
+// openWorktree opens path in settings.editor, suspending the TUI while a
+// terminal editor runs.
+func (m listModel) openWorktree(path string) tea.Cmd {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return func() tea.Msg { return editorClosedMsg{err: err} }
+	}
+	c, err := editor.Command(cfg.Settings.Editor, cfg.Settings.EditorWindow, path)
+	if err != nil {
+		return func() tea.Msg { return editorClosedMsg{err: err} }
+	}
+	return tea.ExecProcess(c, func(err error) tea.Msg { return editorClosedMsg{err: err} })
+}
+
 type worktreeDeletedMsg struct {
 	err error
 }
 
-func (m listModel) deleteWorktree(path string, branch string) tea.Cmd {
+func (m listModel) deleteWorktree(wt worktree.Worktree) tea.Cmd {
+	path, branch := wt.Path, wt.Branch
 	return func() tea.Msg {
 		// Compute common git dir and main worktree before removal
 		common, _ := worktree.GetCommonGitDir(path)
+		if common != "" {
+			l, err := lock.Acquire(lock.RepoPath(common), "gwt list (delete "+wt.Name()+")", lock.DefaultTimeout, nil)
+			if err != nil {
+				return worktreeDeletedMsg{err: err}
+			}
+			defer l.Release()
+		}
 		mainWT, _ := worktree.FindMainWorktree()
+		head := wt.Head
 
 		// Move out of the worktree being deleted so that subsequent
 		// git commands (e.g. reload) don't run in a deleted cwd.
@@ -221,18 +332,25 @@ func (m listModel) deleteWorktree(path string, branch string) tea.Cmd {
 		}
 
 		// Remove worktree
-		err := worktree.Remove(path, false)
+		err := worktree.Remove(m.root, path, false)
 		if err != nil {
 			// If git refuses because the worktree is dirty or has untracked files,
 			// fall back to a forced removal after the user has confirmed.
 			msg := err.Error()
 			if strings.Contains(msg, "contains modified or untracked files") || strings.Contains(msg, "use --force") || strings.Contains(msg, "is not clean") {
-				err = worktree.Remove(path, true)
+				err = worktree.Remove(m.root, path, true)
 			}
 		}
 		if err == nil {
-			// Attempt to delete branch (safe -d). Ignore errors to keep UX smooth.
-			_ = worktree.DeleteBranchWithGitDir(common, branch, false)
+			entry := journal.New("remove", []string{wt.Name()})
+			entry.AddWorktree(journal.ActionRemoved, path, branch, head)
+			if branch != "" {
+				// Attempt to delete branch (safe -d). Ignore errors to keep UX smooth.
+				branchBefore := worktree.BranchSHA(common, branch)
+				_ = worktree.DeleteBranchWithGitDir(common, branch, false)
+				entry.AddBranch(branch, branchBefore, worktree.BranchSHA(common, branch))
+			}
+			_ = journal.Append(common, entry)
 		}
 		return worktreeDeletedMsg{err: err}
 	}
@@ -0,0 +1,92 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/compose"
+	"github.com/nachoal/gwt/internal/config"
+)
+
+// StartCompose starts the compose stack configured by cfg for the worktree at
+// path under a project name unique to the worktree, and records it in the
+// worktree's metadata so that removing the worktree stops it again. Reserved
+// ports are passed to compose as PORT_<NAME> variables.
+func StartCompose(cfg *config.ComposeConfig, project, name, path string, ports map[string]int) (*compose.Stack, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	stack := &compose.Stack{
+		Project:  composeProject(project, name),
+		Files:    cfg.Files,
+		Services: cfg.Services,
+		Dir:      path,
+	}
+	// Record first: a partially started stack still needs `down` on removal.
+	if err := UpdateMetadata(path, func(m *Metadata) { m.Compose = stack }); err != nil {
+		return nil, err
+	}
+	var env []string
+	for _, name := range sortedPortNames(ports) {
+		env = append(env, fmt.Sprintf("%s=%d", PortEnvName(name), ports[name]))
+	}
+	if err := stack.Up(env); err != nil {
+		return stack, err
+	}
+	return stack, nil
+}
+
+// composeProject derives a valid compose project name (lowercase ASCII
+// letters, digits, '-' and '_', starting with a letter or digit) from the gwt
+// project and branch, e.g. "api" and "feature/Foo" give "api-feature-foo".
+func composeProject(project, name string) string {
+	valid := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '-'
+	}, Slug(project+"-"+name))
+	return strings.Trim(collapseDashes(valid), "-_")
+}
+
+// ComposeStack returns the compose stack recorded for the worktree at path, or nil.
+func ComposeStack(path string) *compose.Stack {
+	m, err := ReadMetadata(path)
+	if err != nil || m == nil || m.Compose == nil {
+		return nil
+	}
+	stack := *m.Compose
+	stack.Dir = path
+	return &stack
+}
+
+// ComposeEnv returns the port variables of the worktree at path, read back
+// from its PortsEnvFile, for compose commands run after creation.
+func ComposeEnv(path string) []string {
+	data, err := os.ReadFile(filepath.Join(path, PortsEnvFile))
+	if err != nil {
+		return nil
+	}
+	var env []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && strings.Contains(line, "=") {
+			env = append(env, line)
+		}
+	}
+	return env
+}
+
+// stopCompose runs `docker compose down -v` for a worktree about to be removed.
+// Failures are reported but do not block the removal.
+func stopCompose(path string) {
+	stack := ComposeStack(path)
+	if stack == nil {
+		return
+	}
+	if err := stack.Down(ComposeEnv(path)); err != nil {
+		fmt.Fprintf(os.Stderr, "Note: could not stop compose project %s: %v\n", stack.Project, err)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build unix
+
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nachoal/gwt/internal/config"
+)
+
+// fakeDocker puts a docker on PATH that logs its arguments, directory and
+// compose variables, one call per line, and returns the log path.
+func fakeDocker(t *testing.T) string {
+	t.Helper()
+	bin := t.TempDir()
+	log := filepath.Join(bin, "docker.log")
+	script := "#!/bin/sh\n" +
+		`echo "$* | dir=$(pwd) project=$COMPOSE_PROJECT_NAME web=$PORT_WEB" >> "` + log + "\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func dockerCalls(t *testing.T, log string) []string {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestComposeLifecycle(t *testing.T) {
+	clone, _ := testClone(t)
+	chdir(t, clone)
+	log := fakeDocker(t)
+
+	path := filepath.Join(t.TempDir(), "repo", "feature", "Foo")
+	if err := CreateAt(CreateSpec{Mode: ModeNew, Name: "feature/Foo", Branch: "feature/Foo", Ref: "main"}, path); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.ComposeConfig{Files: []string{"compose.yml", "compose.dev.yml"}, Services: []string{"db", "cache"}}
+	stack, err := StartCompose(cfg, "repo", "feature/Foo", path, map[string]int{"web": 3100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stack.Project != "repo-feature-foo" {
+		t.Errorf("project = %q, want repo-feature-foo", stack.Project)
+	}
+	dir, _ := filepath.EvalSymlinks(path)
+	want := []string{
+		"compose -p repo-feature-foo -f compose.yml -f compose.dev.yml up -d db cache | dir=" + dir + " project=repo-feature-foo web=3100",
+	}
+	if got := dockerCalls(t, log); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("after up, docker calls:\n got %q\nwant %q", got, want)
+	}
+	if recorded := ComposeStack(path); recorded == nil || recorded.Project != stack.Project {
+		t.Fatalf("ComposeStack(%s) = %+v, want the started stack", path, recorded)
+	}
+
+	// Removal passes the ports back in from the env file; write it where a
+	// real creation would, ignored so the worktree stays clean.
+	if err := os.WriteFile(filepath.Join(path, PortsEnvFile), []byte("PORT_WEB=3100\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	excludes := git(t, path, "rev-parse", "--git-path", "info/exclude")
+	if !filepath.IsAbs(excludes) {
+		excludes = filepath.Join(path, excludes)
+	}
+	if err := os.MkdirAll(filepath.Dir(excludes), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(excludes, []byte(PortsEnvFile+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Remove("", path, false); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, "compose -p repo-feature-foo -f compose.yml -f compose.dev.yml down -v --remove-orphans | dir="+dir+" project=repo-feature-foo web=3100")
+	if got := dockerCalls(t, log); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("after remove, docker calls:\n got %q\nwant %q", got, want)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s still exists", path)
+	}
+}
+
+func TestComposeNotStoppedWhenRemovalIsRefused(t *testing.T) {
+	clone, _ := testClone(t)
+	chdir(t, clone)
+	log := fakeDocker(t)
+
+	path := filepath.Join(t.TempDir(), "repo", "dirty")
+	if err := CreateAt(CreateSpec{Mode: ModeNew, Name: "dirty", Branch: "dirty", Ref: "main"}, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StartCompose(&config.ComposeConfig{}, "repo", "dirty", path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "scratch"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Remove("", path, false); err == nil {
+		t.Fatal("expected a dirty worktree to be refused")
+	}
+	calls := dockerCalls(t, log)
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "compose -p repo-dirty up -d |") {
+		t.Errorf("docker calls = %q, want only the up", calls)
+	}
+}
@@ -0,0 +1,204 @@
+package worktree
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Creation modes reported by ResolveCreateSpec.
+const (
+	// ModeExisting checks out an existing local branch.
+	ModeExisting = "existing"
+	// ModeNew creates a new branch from a base ref.
+	ModeNew = "new"
+	// ModeTrack creates a local branch tracking a remote branch.
+	ModeTrack = "track"
+	// ModeDetach checks out a tag, commit or other ref with a detached HEAD.
+	ModeDetach = "detach"
+)
+
+// CreateSpec describes how a worktree will be created.
+type CreateSpec struct {
+	Mode string
+	// Name is used for the worktree path (.Branch in settings.path_template).
+	Name string
+	// Branch is the local branch checked out in the worktree; empty when detached.
+	Branch string
+	// Ref is the starting point: the base for ModeNew, the remote branch for
+	// ModeTrack and the target for ModeDetach.
+	Ref string
+}
+
+// ResolveCreateSpec works out how to create a worktree for arg:
+//
+//   - detach: check out arg (a tag, commit or any ref) with a detached HEAD
+//   - "<remote>/<branch>" or track: create a local branch tracking the remote branch
+//   - an existing local branch: check it out
+//   - otherwise: create a new branch arg from the base ref from
+//
+// Refs that are unknown locally are fetched from the remote first.
+func ResolveCreateSpec(arg, from string, detach, track bool) (CreateSpec, error) {
+	if detach {
+		if !refExists(arg) {
+			if err := fetchForRef(DefaultRemote(), arg); err != nil {
+				return CreateSpec{}, err
+			}
+			if !refExists(arg) {
+				return CreateSpec{}, fmt.Errorf("unknown ref '%s'", arg)
+			}
+		}
+		name := arg
+		if ValidateBranchName(name) != nil {
+			name = shortRev(arg)
+		}
+		return CreateSpec{Mode: ModeDetach, Name: name, Ref: arg}, nil
+	}
+
+	remote, branch := splitRemoteBranch(arg)
+	if remote == "" && track {
+		remote, branch = DefaultRemote(), arg
+		if remote == "" {
+			return CreateSpec{}, fmt.Errorf("--track requires a configured remote")
+		}
+	}
+	if remote != "" {
+		if err := ValidateBranchName(branch); err != nil {
+			return CreateSpec{}, err
+		}
+		if localBranchExists(branch) {
+			return CreateSpec{Mode: ModeExisting, Name: branch, Branch: branch, Ref: branch}, nil
+		}
+		remoteRef := remote + "/" + branch
+		if !refExists("refs/remotes/" + remoteRef) {
+			if err := gitFetch(remote, branch); err != nil {
+				return CreateSpec{}, fmt.Errorf("branch '%s' not found on %s: %w", branch, remote, err)
+			}
+		}
+		return CreateSpec{Mode: ModeTrack, Name: branch, Branch: branch, Ref: remoteRef}, nil
+	}
+
+	if err := ValidateBranchName(arg); err != nil {
+		return CreateSpec{}, err
+	}
+	if localBranchExists(arg) {
+		return CreateSpec{Mode: ModeExisting, Name: arg, Branch: arg, Ref: arg}, nil
+	}
+	if from == "" {
+		return CreateSpec{}, fmt.Errorf("no base ref to create '%s' from", arg)
+	}
+	if !refExists(from) {
+		remote := DefaultRemote()
+		if err := fetchForRef(remote, from); err != nil {
+			return CreateSpec{}, err
+		}
+		// A base that only exists on the remote (e.g. "develop") resolves via its tracking ref.
+		if !refExists(from) && remote != "" && refExists("refs/remotes/"+remote+"/"+from) {
+			from = remote + "/" + from
+		}
+		if !refExists(from) {
+			return CreateSpec{}, fmt.Errorf("unknown base ref '%s'", from)
+		}
+	}
+	return CreateSpec{Mode: ModeNew, Name: arg, Branch: arg, Ref: from}, nil
+}
+
+// CreateFromSpec runs `git worktree add` for spec. Most callers want CreateAt.
+func CreateFromSpec(spec CreateSpec, targetPath string) error {
+	var args []string
+	switch spec.Mode {
+	case ModeExisting:
+		args = []string{"worktree", "add", targetPath, spec.Branch}
+	case ModeNew:
+		args = []string{"worktree", "add", "-b", spec.Branch, targetPath, spec.Ref}
+	case ModeTrack:
+		args = []string{"worktree", "add", "--track", "-b", spec.Branch, targetPath, spec.Ref}
+	case ModeDetach:
+		args = []string{"worktree", "add", "--detach", targetPath, spec.Ref}
+	default:
+		return fmt.Errorf("unknown create mode '%s'", spec.Mode)
+	}
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// splitRemoteBranch splits "<remote>/<branch>" when <remote> is a configured remote.
+func splitRemoteBranch(arg string) (string, string) {
+	i := strings.Index(arg, "/")
+	if i <= 0 {
+		return "", arg
+	}
+	for _, r := range remotes() {
+		if r == arg[:i] {
+			return r, arg[i+1:]
+		}
+	}
+	return "", arg
+}
+
+func remotes() []string {
+	out, err := exec.Command("git", "remote").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// DefaultRemote returns "origin" if configured, else the first remote, else "".
+func DefaultRemote() string {
+	rs := remotes()
+	for _, r := range rs {
+		if r == "origin" {
+			return r
+		}
+	}
+	if len(rs) > 0 {
+		return rs[0]
+	}
+	return ""
+}
+
+func localBranchExists(branch string) bool {
+	return exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
+func refExists(ref string) bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}").Run() == nil
+}
+
+func shortRev(ref string) string {
+	out, err := exec.Command("git", "rev-parse", "--short", ref).Output()
+	if err != nil {
+		return ref
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// fetchForRef fetches tags and branches from remote so that ref can be
+// resolved, then tries ref itself (e.g. a commit SHA) as a last resort.
+func fetchForRef(remote, ref string) error {
+	if remote == "" {
+		return fmt.Errorf("unknown ref '%s' and no remote to fetch it from", ref)
+	}
+	if err := gitFetch(remote, "--tags"); err != nil {
+		return err
+	}
+	if refExists(ref) || refExists("refs/remotes/"+remote+"/"+ref) {
+		return nil
+	}
+	// Servers usually allow fetching reachable commits by SHA.
+	_ = gitFetch(remote, ref)
+	return nil
+}
+
+func gitFetch(remote string, args ...string) error {
+	fetchArgs := append([]string{"fetch", "--quiet", remote}, args...)
+	output, err := exec.Command("git", fetchArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git fetch %s failed: %w: %s", remote, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
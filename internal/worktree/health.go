@@ -0,0 +1,98 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Link states reported by CheckLink.
+const (
+	LinkOK = "ok"
+	// LinkMissing means the ".git" file points to a git dir that does not
+	// exist: the repository was deleted or moved.
+	LinkMissing = "missing"
+	// LinkBroken means the git dir exists but records another location for
+	// the worktree: the worktree was moved or copied.
+	LinkBroken = "broken"
+	// LinkInvalid means the ".git" file could not be read or parsed.
+	LinkInvalid = "invalid"
+)
+
+// CheckLink checks the two-way link between the worktree at path and its
+// private git dir (<common>/worktrees/<id>), returning a Link* state and the
+// git dir the worktree points to.
+func CheckLink(path string) (string, string) {
+	gitDir, err := ReadGitFile(path)
+	if err != nil {
+		return LinkInvalid, ""
+	}
+	if _, err := os.Stat(gitDir); err != nil {
+		return LinkMissing, gitDir
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "gitdir"))
+	if err != nil {
+		// The main worktree's git dir has no gitdir file, nor do submodules.
+		if _, serr := os.Stat(filepath.Join(gitDir, "commondir")); serr != nil {
+			return LinkOK, gitDir
+		}
+		return LinkBroken, gitDir
+	}
+	back := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(back) {
+		back = filepath.Join(gitDir, back)
+	}
+	if !SamePath(back, filepath.Join(path, ".git")) {
+		return LinkBroken, gitDir
+	}
+	return LinkOK, gitDir
+}
+
+// ReadGitFile returns the absolute git dir named by the "gitdir:" line of the
+// ".git" file in the worktree at path.
+func ReadGitFile(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(path, ".git"))
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", os.ErrInvalid
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// Repair runs `git worktree repair` in the common git dir for the worktrees
+// at paths, reconnecting them after the repository or the worktrees moved.
+func Repair(commonGitDir string, paths ...string) error {
+	return gitIn(commonGitDir, append([]string{"worktree", "repair"}, paths...)...)
+}
+
+// RepairFrom runs `git worktree repair` inside the worktree at path, which
+// fixes the repository's record of a moved worktree.
+func RepairFrom(path string) error {
+	return gitIn(path, "worktree", "repair")
+}
+
+// Prune runs `git worktree prune` for the repository's stale registrations.
+func Prune(commonGitDir string) error {
+	return gitIn(commonGitDir, "worktree", "prune")
+}
+
+func gitIn(dir string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("git %s: %s", strings.Join(args[:2], " "), strings.TrimSpace(string(output)))
+		}
+		return err
+	}
+	return nil
+}
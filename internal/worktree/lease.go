@@ -0,0 +1,65 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nachoal/gwt/internal/lease"
+)
+
+// ActiveLease returns the unexpired lease on the worktree at path, or nil.
+func ActiveLease(path string) *lease.Lease {
+	if s := loadLeases(path); s != nil {
+		return s.Active(path, time.Now())
+	}
+	return nil
+}
+
+// RecordedLease returns the lease recorded for the worktree at path, expired
+// or not, or nil.
+func RecordedLease(path string) *lease.Lease {
+	if s := loadLeases(path); s != nil {
+		return s.Find(path)
+	}
+	return nil
+}
+
+func loadLeases(path string) *lease.Set {
+	common, err := GetCommonGitDir(path)
+	if err != nil {
+		return nil
+	}
+	s, err := lease.Load(common)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// checkLease refuses to remove a worktree under an active lease. An expired
+// lease is dropped and its git lock lifted so the removal can go ahead.
+func checkLease(path string) error {
+	common, err := GetCommonGitDir(path)
+	if err != nil {
+		// Let `git worktree remove` report the broken worktree.
+		return nil
+	}
+	s, err := lease.Load(common)
+	if err != nil || s.Find(path) == nil {
+		return err
+	}
+	return lease.Update(common, func(s *lease.Set) error {
+		l := s.Find(path)
+		if l == nil {
+			return nil
+		}
+		if !l.Expired(time.Now()) {
+			return &lease.ErrLeased{Lease: *l}
+		}
+		fmt.Fprintf(os.Stderr, "Note: dropping lease of %s, expired %s\n", l.Owner, l.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		s.Remove(path)
+		_ = Unlock(path)
+		return nil
+	})
+}
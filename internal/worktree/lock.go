@@ -0,0 +1,36 @@
+package worktree
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Lock applies `git worktree lock` to the worktree at path, with an
+// optional reason.
+func Lock(path, reason string) error {
+	if reason == "" {
+		return gitWorktree("lock", path)
+	}
+	return gitWorktree("lock", "--reason", reason, path)
+}
+
+// Unlock removes a `git worktree lock` from the worktree at path.
+func Unlock(path string) error {
+	return gitWorktree("unlock", path)
+}
+
+func gitWorktree(args ...string) error {
+	cmd := exec.Command("git", append([]string{"worktree"}, args...)...)
+	if mainWT, _ := FindMainWorktree(); mainWT != "" {
+		cmd.Dir = mainWT
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("git worktree %s: %s", args[0], strings.TrimSpace(string(output)))
+		}
+		return err
+	}
+	return nil
+}
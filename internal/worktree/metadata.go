@@ -0,0 +1,226 @@
+package worktree
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nachoal/gwt/internal/compose"
+	"github.com/nachoal/gwt/internal/session"
+)
+
+// Metadata is what gwt remembers about a worktree beyond what git records.
+// It is stored as gwt.json in the worktree's private git dir
+// (.git/worktrees/<name>/), so it follows `git worktree move` and disappears
+// with the worktree.
+type Metadata struct {
+	// Base is the branch the worktree's branch was created from; done, sync
+	// and clean use it instead of the repository default branch.
+	Base        string    `json:"base,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Creator     string    `json:"creator,omitempty"`
+	GwtVersion  string    `json:"gwt_version,omitempty"`
+	Description string    `json:"description,omitempty"`
+	IssueURL    string    `json:"issue_url,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	// Compose is the worktree's Docker Compose stack, stopped on removal.
+	Compose *compose.Stack `json:"compose,omitempty"`
+	// Session is the tmux/zellij session opened for the worktree, killed on removal.
+	Session *session.Session `json:"session,omitempty"`
+}
+
+const metadataFile = "gwt.json"
+
+// MetadataPath returns the metadata file location for the worktree at path.
+func MetadataPath(path string) (string, error) {
+	out, err := exec.Command("git", "-C", path, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git worktree: %s", path)
+	}
+	return filepath.Join(strings.TrimSpace(string(out)), metadataFile), nil
+}
+
+// ReadMetadata reads the metadata of the worktree at path. Worktrees without
+// metadata (e.g. not created by gwt) return nil and no error.
+func ReadMetadata(path string) (*Metadata, error) {
+	file, err := MetadataPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	return &m, nil
+}
+
+// WriteMetadata replaces the metadata of the worktree at path.
+func WriteMetadata(path string, m *Metadata) error {
+	file, err := MetadataPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), metadataFile+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// UpdateMetadata applies fn to the metadata of the worktree at path, starting
+// from empty metadata if there is none yet.
+func UpdateMetadata(path string, fn func(*Metadata)) error {
+	m, err := ReadMetadata(path)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		m = &Metadata{}
+	}
+	fn(m)
+	return WriteMetadata(path, m)
+}
+
+// NewMetadata returns the metadata recorded for a worktree gwt creates from spec.
+func NewMetadata(spec CreateSpec, version string) *Metadata {
+	m := &Metadata{
+		CreatedAt:  time.Now().UTC(),
+		Creator:    creator(),
+		GwtVersion: version,
+	}
+	if spec.Mode == ModeNew {
+		m.Base = baseBranchName(spec.Ref)
+	}
+	return m
+}
+
+// RecordedBase returns the base branch recorded for the worktree at path, or "".
+func RecordedBase(path string) string {
+	m, err := ReadMetadata(path)
+	if err != nil || m == nil {
+		return ""
+	}
+	return m.Base
+}
+
+// baseBranchName maps a start ref to the branch it names: "main" and
+// "origin/main" both give "main". Tags and commits give "".
+func baseBranchName(ref string) string {
+	if localBranchExists(ref) {
+		return ref
+	}
+	if remote, branch := splitRemoteBranch(ref); remote != "" {
+		return branch
+	}
+	return ""
+}
+
+func creator() string {
+	if out, err := exec.Command("git", "config", "user.email").Output(); err == nil {
+		if email := strings.TrimSpace(string(out)); email != "" {
+			return email
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// HasTags reports whether m carries every tag in tags. Nil metadata has no tags.
+func (m *Metadata) HasTags(tags []string) bool {
+	for _, want := range tags {
+		found := false
+		if m != nil {
+			for _, t := range m.Tags {
+				if t == want {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// AddTags adds tags that are not present yet, keeping their order.
+func (m *Metadata) AddTags(tags ...string) {
+	for _, t := range tags {
+		if !m.HasTags([]string{t}) {
+			m.Tags = append(m.Tags, t)
+		}
+	}
+}
+
+// RemoveTags drops the given tags.
+func (m *Metadata) RemoveTags(tags ...string) {
+	kept := m.Tags[:0]
+	for _, t := range m.Tags {
+		drop := false
+		for _, r := range tags {
+			if t == r {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, t)
+		}
+	}
+	m.Tags = kept
+	if len(m.Tags) == 0 {
+		m.Tags = nil
+	}
+}
+
+// ValidateTag rejects tags that would not survive the plain output formats.
+func ValidateTag(tag string) error {
+	if tag == "" || strings.ContainsAny(tag, ", \t\n") {
+		return fmt.Errorf("invalid tag %q: must be non-empty without spaces or commas", tag)
+	}
+	return nil
+}
+
+// Label is a one-line summary of the tags and description, e.g.
+// "#review #urgent waiting on API review".
+func (m *Metadata) Label() string {
+	if m == nil {
+		return ""
+	}
+	var parts []string
+	for _, t := range m.Tags {
+		parts = append(parts, "#"+t)
+	}
+	if m.Description != "" {
+		parts = append(parts, m.Description)
+	}
+	return strings.Join(parts, " ")
+}
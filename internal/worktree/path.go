@@ -0,0 +1,116 @@
+package worktree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/nachoal/gwt/internal/registry"
+)
+
+// DefaultPathTemplate reproduces the historical layout: <root>/<project>/<branch>.
+const DefaultPathTemplate = "{{.Root}}/{{.Project}}/{{.Branch}}"
+
+// PathData is the data available to settings.path_template.
+type PathData struct {
+	Root    string
+	Project string
+	Branch  string
+}
+
+// pathFuncs are the functions available to settings.path_template.
+var pathFuncs = template.FuncMap{
+	"slug": Slug,
+}
+
+// ValidateBranchName rejects names git would not accept as a branch, using
+// `git check-ref-format` semantics (no "..", spaces, control characters,
+// "~^:?*[\", trailing ".lock", and so on).
+func ValidateBranchName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("branch name must not be empty")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid branch name '%s': must not start with '-'", name)
+	}
+	if name == "HEAD" {
+		return fmt.Errorf("invalid branch name '%s'", name)
+	}
+	cmd := exec.Command("git", "check-ref-format", "refs/heads/"+name)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("invalid branch name '%s' (see 'git help check-ref-format')", name)
+	}
+	return nil
+}
+
+// Slug turns a branch name into a single, filesystem-friendly path segment:
+// "feature/Foo Bar" becomes "feature-foo-bar". A name without letters or
+// digits, such as "+++", gives a short hash of it instead of an empty slug.
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	slug := strings.Trim(b.String(), "-.")
+	if slug == "" && strings.TrimSpace(s) != "" {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:4])
+	}
+	return slug
+}
+
+// RenderWorktreePath renders a path template (settings.path_template) for a
+// branch. An empty template uses DefaultPathTemplate. The result must be an
+// absolute path; a leading "~/" is expanded.
+func RenderWorktreePath(tmpl, root, projectName, branchName string) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = DefaultPathTemplate
+	}
+	t, err := template.New("path_template").Funcs(pathFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid settings.path_template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, PathData{Root: root, Project: projectName, Branch: branchName}); err != nil {
+		return "", fmt.Errorf("invalid settings.path_template: %w", err)
+	}
+
+	path := strings.TrimSpace(buf.String())
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("settings.path_template must render an absolute path, got %q", path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// Register records a worktree in the registry under root so that ListFromRoot
+// finds it regardless of the layout produced by the path template.
+func Register(root, projectName, branchName, path string) error {
+	commonDir, _ := GetCommonGitDir(path)
+	return registry.Register(root, registry.Entry{
+		Path:      path,
+		Project:   projectName,
+		Branch:    branchName,
+		CommonDir: commonDir,
+	})
+}
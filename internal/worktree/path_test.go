@@ -0,0 +1,106 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateBranchName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"feature/foo", true},
+		{"fix-123", true},
+		{"+++", true},
+		{"v1.2", true},
+		{"", false},
+		{"  ", false},
+		{"-x", false},
+		{"HEAD", false},
+		{"a..b", false},
+		{"with space", false},
+		{"a~1", false},
+		{"a^", false},
+		{"a:b", false},
+		{"a?", false},
+		{"a*", false},
+		{"a[b", false},
+		{`a\b`, false},
+		{"foo.lock", false},
+		{"foo/", false},
+		{"/foo", false},
+		{"foo//bar", false},
+		{".hidden", false},
+		{"a@{b", false},
+	}
+	for _, tt := range tests {
+		err := ValidateBranchName(tt.name)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateBranchName(%q) = %v, want valid %t", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"feature/Foo Bar", "feature-foo-bar"},
+		{"fix_123", "fix_123"},
+		{"v1.2", "v1.2"},
+		{"--a//b--", "a-b"},
+		{".hidden.", "hidden"},
+		{"Ünïcode/Ärger", "ünïcode-ärger"},
+		{"", ""},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.in); got != tt.want {
+			t.Errorf("Slug(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// Names without letters or digits hash instead of vanishing.
+	plus, dots := Slug("+++"), Slug("...")
+	if len(plus) != 8 || plus == dots || Slug("+++") != plus {
+		t.Errorf("Slug(+++) = %q, Slug(...) = %q: want distinct, stable 8-character hashes", plus, dots)
+	}
+}
+
+func TestRenderWorktreePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tmpl, branch string
+		want         string
+		wantErr      string
+	}{
+		{tmpl: "", branch: "feature/foo", want: "/wt/api/feature/foo"},
+		{tmpl: DefaultPathTemplate, branch: "main", want: "/wt/api/main"},
+		{tmpl: "{{.Root}}/{{.Project}}-{{slug .Branch}}", branch: "feature/Foo", want: "/wt/api-feature-foo"},
+		{tmpl: "{{.Root}}/{{.Project}}/{{slug .Branch}}", branch: "+++", want: "/wt/api/" + Slug("+++")},
+		{tmpl: "  {{.Root}}/{{.Project}}/{{.Branch}}/  ", branch: "x", want: "/wt/api/x"},
+		{tmpl: "~/src/{{.Project}}/{{.Branch}}", branch: "x", want: filepath.Join(home, "src/api/x")},
+		{tmpl: "{{.Project}}/{{.Branch}}", branch: "x", wantErr: "must render an absolute path"},
+		{tmpl: "{{.Root}}/{{.Nope}}", branch: "x", wantErr: "invalid settings.path_template"},
+		{tmpl: "{{.Root}}/{{slug .Branch", branch: "x", wantErr: "invalid settings.path_template"},
+		{tmpl: "{{.Root}}/{{upper .Branch}}", branch: "x", wantErr: "invalid settings.path_template"},
+	}
+	for _, tt := range tests {
+		got, err := RenderWorktreePath(tt.tmpl, "/wt", "api", tt.branch)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RenderWorktreePath(%q, %q) error = %v, want %q", tt.tmpl, tt.branch, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("RenderWorktreePath(%q, %q) = %q, %v, want %q", tt.tmpl, tt.branch, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package worktree
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/registry"
+)
+
+// PortsEnvFile is the env file gwt writes into worktrees that have ports.
+const PortsEnvFile = ".env.gwt"
+
+// maxPortOffset bounds the search for a free offset.
+const maxPortOffset = 1000
+
+// AssignPorts reserves ports for the worktree at path in the registry under
+// root and writes them to PortsEnvFile. Each worktree gets one offset that is
+// added to every base port in ports ("web: 3000" becomes 3001, 3002, ...); the
+// offset is the smallest one whose ports are neither reserved by another
+// worktree under root, nor a base port of the main checkout, nor in use.
+// Re-running keeps an existing reservation. It returns the assigned ports.
+func AssignPorts(root, path string, ports map[string]int) (map[string]int, error) {
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	if err := checkPorts(ports); err != nil {
+		return nil, err
+	}
+
+	var offset int
+	var assigned map[string]int
+	err := registry.Update(root, func(r *registry.Registry) error {
+		e := r.Find(path)
+		if e != nil && e.Ports != nil && samePortNames(e.Ports, ports) {
+			offset, assigned = e.PortOffset, e.Ports
+			return nil
+		}
+
+		reserved := r.ReservedPorts(path)
+		for _, base := range ports {
+			reserved[base] = true
+		}
+		for off := 1; off <= maxPortOffset; off++ {
+			candidate, ok := portsAt(ports, off, reserved)
+			if ok {
+				offset, assigned = off, candidate
+				break
+			}
+		}
+		if assigned == nil {
+			return fmt.Errorf("no free port offset found (tried 1-%d)", maxPortOffset)
+		}
+
+		entry := registry.Entry{Path: path, PortOffset: offset, Ports: assigned}
+		if e != nil {
+			entry.Project, entry.Branch, entry.CommonDir, entry.CreatedAt = e.Project, e.Branch, e.CommonDir, e.CreatedAt
+		} else {
+			entry.CommonDir, _ = GetCommonGitDir(path)
+		}
+		r.Put(entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writePortsEnv(path, offset, assigned); err != nil {
+		return nil, err
+	}
+	return assigned, nil
+}
+
+// checkPorts rejects invalid base ports and base ports shared by two
+// services, which would get the same port at every offset.
+func checkPorts(ports map[string]int) error {
+	owner := make(map[int]string, len(ports))
+	for _, name := range sortedPortNames(ports) {
+		base := ports[name]
+		if base <= 0 || base > 65535 {
+			return fmt.Errorf("invalid port %d for '%s'", base, name)
+		}
+		if other, ok := owner[base]; ok {
+			return fmt.Errorf("ports '%s' and '%s' both use base port %d", other, name, base)
+		}
+		owner[base] = name
+	}
+	return nil
+}
+
+// ReleasePorts drops the registry entry for a removed worktree, freeing its ports.
+func ReleasePorts(root, path string) error {
+	return registry.Update(root, func(r *registry.Registry) error {
+		r.Remove(path)
+		return nil
+	})
+}
+
+// PortEnvName is the env variable for a named port: "web" gives PORT_WEB.
+func PortEnvName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return "PORT_" + b.String()
+}
+
+// FormatPorts renders assigned ports as "PORT_DB=5433 PORT_WEB=3001".
+func FormatPorts(ports map[string]int) string {
+	var parts []string
+	for _, name := range sortedPortNames(ports) {
+		parts = append(parts, fmt.Sprintf("%s=%d", PortEnvName(name), ports[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func portsAt(ports map[string]int, off int, reserved map[int]bool) (map[string]int, bool) {
+	candidate := make(map[string]int, len(ports))
+	for name, base := range ports {
+		p := base + off
+		if p > 65535 || reserved[p] || !portFree(p) {
+			return nil, false
+		}
+		candidate[name] = p
+	}
+	return candidate, true
+}
+
+func portFree(port int) bool {
+	l, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+func samePortNames(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedPortNames(ports map[string]int) []string {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writePortsEnv(path string, offset int, ports map[string]int) error {
+	var b strings.Builder
+	b.WriteString("# Generated by gwt: ports reserved for this worktree. Do not edit.\n")
+	fmt.Fprintf(&b, "GWT_PORT_OFFSET=%d\n", offset)
+	for _, name := range sortedPortNames(ports) {
+		fmt.Fprintf(&b, "%s=%d\n", PortEnvName(name), ports[name])
+	}
+	if err := os.WriteFile(filepath.Join(path, PortsEnvFile), []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	// Keep the generated file out of `git status` and `git worktree remove` checks.
+	if commonDir, err := GetCommonGitDir(path); err == nil {
+		_ = ensureExcluded(commonDir, "/"+PortsEnvFile)
+	}
+	return nil
+}
+
+// ensureExcluded adds pattern to the repository's info/exclude if missing.
+func ensureExcluded(commonDir, pattern string) error {
+	file := filepath.Join(commonDir, "info", "exclude")
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return os.WriteFile(file, []byte(content+pattern+"\n"), 0o644)
+}
+
+// releaseRegistryEntry frees the registry entry (and ports) of a removed
+// worktree under root. It is best effort: Load also drops entries whose path
+// is gone.
+func releaseRegistryEntry(root, path string) {
+	if root == "" {
+		return
+	}
+	if _, err := os.Stat(registry.Path(root)); err != nil {
+		return
+	}
+	_ = ReleasePorts(root, path)
+}
@@ -0,0 +1,130 @@
+package worktree
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// freeBase returns a base port whose first few offsets are free.
+func freeBase(t *testing.T) int {
+	t.Helper()
+	for base := 42000; base < 60000; base += 100 {
+		free := true
+		for off := 0; off < 20 && free; off++ {
+			free = portFree(base + off)
+		}
+		if free {
+			return base
+		}
+	}
+	t.Skip("no free port range")
+	return 0
+}
+
+func worktreeDirs(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range names {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func TestAssignPorts(t *testing.T) {
+	root := t.TempDir()
+	base := freeBase(t)
+	ports := map[string]int{"web": base, "db": base + 5}
+	wt := worktreeDirs(t, "a", "b", "c", "d")
+
+	assign := func(path string, want int) {
+		t.Helper()
+		got, err := AssignPorts(root, path, ports)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantPorts := map[string]int{"web": base + want, "db": base + 5 + want}; !reflect.DeepEqual(got, wantPorts) {
+			t.Errorf("%s: ports %v, want offset %d (%v)", filepath.Base(path), got, want, wantPorts)
+		}
+	}
+
+	assign(wt[0], 1)
+	assign(wt[1], 2)
+	// Re-running keeps the reservation.
+	assign(wt[0], 1)
+
+	// Offset 3 would give web a port someone listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(base+3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	assign(wt[2], 4)
+
+	// Offset 5 would give web the db's base port, which the main checkout
+	// uses, and offsets 6 and 7 the db ports of a and b.
+	assign(wt[3], 8)
+
+	// Releasing a worktree frees its offset for the next one.
+	if err := ReleasePorts(root, wt[1]); err != nil {
+		t.Fatal(err)
+	}
+	assign(worktreeDirs(t, "e")[0], 2)
+
+	data, err := os.ReadFile(filepath.Join(wt[0], PortsEnvFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"GWT_PORT_OFFSET=1", "PORT_WEB=" + strconv.Itoa(base+1), "PORT_DB=" + strconv.Itoa(base+6)} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("%s lacks %s:\n%s", PortsEnvFile, line, data)
+		}
+	}
+}
+
+func TestAssignPortsNewServiceReassigns(t *testing.T) {
+	root := t.TempDir()
+	base := freeBase(t)
+	wt := worktreeDirs(t, "a")[0]
+	if _, err := AssignPorts(root, wt, map[string]int{"web": base}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AssignPorts(root, wt, map[string]int{"web": base, "api": base + 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"web": base + 1, "api": base + 51}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ports %v, want %v", got, want)
+	}
+}
+
+func TestAssignPortsRejectsInvalidPorts(t *testing.T) {
+	wt := worktreeDirs(t, "a")[0]
+	tests := []struct {
+		ports   map[string]int
+		wantErr string
+	}{
+		{map[string]int{"web": 3000, "api": 3000}, "ports 'api' and 'web' both use base port 3000"},
+		{map[string]int{"web": 0}, "invalid port 0 for 'web'"},
+		{map[string]int{"web": 70000}, "invalid port 70000 for 'web'"},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		_, err := AssignPorts(root, wt, tt.ports)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("AssignPorts(%v) error = %v, want %q", tt.ports, err, tt.wantErr)
+		}
+		if _, err := os.Stat(filepath.Join(wt, PortsEnvFile)); !os.IsNotExist(err) {
+			t.Errorf("AssignPorts(%v) wrote %s", tt.ports, PortsEnvFile)
+		}
+	}
+}
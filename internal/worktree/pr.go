@@ -0,0 +1,109 @@
+package worktree
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PullRequestHead is the fetched head of a GitHub pull request or GitLab merge request.
+type PullRequestHead struct {
+	Number int
+	// Forge is "github" or "gitlab", depending on which ref namespace the remote exposes.
+	Forge string
+	// Ref is the local ref the head was fetched into (refs/gwt/pr/<remote>/<number>).
+	Ref     string
+	SHA     string
+	Subject string
+}
+
+// FetchPullRequest fetches refs/pull/<n>/head (GitHub) or
+// refs/merge-requests/<n>/head (GitLab) from remote into a private local ref.
+// It only needs git access to the remote, no forge API.
+func FetchPullRequest(remote string, number int) (PullRequestHead, error) {
+	if remote == "" {
+		return PullRequestHead{}, fmt.Errorf("no remote configured to fetch pull requests from")
+	}
+	localRef := fmt.Sprintf("refs/gwt/pr/%s/%d", remote, number)
+	sources := []struct{ forge, ref string }{
+		{"github", fmt.Sprintf("refs/pull/%d/head", number)},
+		{"gitlab", fmt.Sprintf("refs/merge-requests/%d/head", number)},
+	}
+
+	var errs []string
+	for _, src := range sources {
+		err := gitFetch(remote, "--no-tags", "+"+src.ref+":"+localRef)
+		if err != nil {
+			errs = append(errs, src.ref)
+			continue
+		}
+		sha, err := exec.Command("git", "rev-parse", localRef).Output()
+		if err != nil {
+			return PullRequestHead{}, err
+		}
+		subject, _ := exec.Command("git", "log", "-1", "--format=%s", localRef).Output()
+		return PullRequestHead{
+			Number:  number,
+			Forge:   src.forge,
+			Ref:     localRef,
+			SHA:     strings.TrimSpace(string(sha)),
+			Subject: strings.TrimSpace(string(subject)),
+		}, nil
+	}
+	return PullRequestHead{}, fmt.Errorf("pull request #%d not found on %s (tried %s)", number, remote, strings.Join(errs, ", "))
+}
+
+// PullRequestBranch returns the local branch name for a pull request:
+// pr/<number>-<slug>, with the slug derived from the head commit subject
+// unless one is given. Dots become dashes, and a name git would still reject
+// falls back to pr/<number>.
+func PullRequestBranch(number int, slug string) string {
+	fallback := fmt.Sprintf("pr/%d", number)
+	// Slug would hash a subject without letters or digits; pr/<n> reads better.
+	if !strings.ContainsFunc(slug, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return fallback
+	}
+	slug = collapseDashes(strings.ReplaceAll(Slug(slug), ".", "-"))
+	if r := []rune(slug); len(r) > 40 {
+		slug = string(r[:40])
+		if i := strings.LastIndex(slug, "-"); i > 20 {
+			slug = slug[:i]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+	if slug == "" {
+		return fallback
+	}
+	branch := fallback + "-" + slug
+	if ValidateBranchName(branch) != nil {
+		return fallback
+	}
+	return branch
+}
+
+// IsPullRequestBranch reports whether branch is a gwt pull request branch for number.
+func IsPullRequestBranch(branch string, number int) bool {
+	prefix := "pr/" + strconv.Itoa(number)
+	return branch == prefix || strings.HasPrefix(branch, prefix+"-")
+}
+
+// IsAncestor reports whether ancestor is reachable from descendant.
+func IsAncestor(ancestor, descendant string) bool {
+	return exec.Command("git", "merge-base", "--is-ancestor", ancestor, descendant).Run() == nil
+}
+
+// FindPullRequestBranch returns an existing local pr/<number>[-slug] branch, or "".
+func FindPullRequestBranch(number int) string {
+	out, err := exec.Command("git", "for-each-ref", "--format=%(refname:short)", "refs/heads/pr/").Output()
+	if err != nil {
+		return ""
+	}
+	for _, branch := range strings.Fields(string(out)) {
+		if IsPullRequestBranch(branch, number) {
+			return branch
+		}
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitEnv isolates git from the user's configuration and gives commits an
+// identity.
+func gitEnv(t *testing.T) {
+	t.Helper()
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME": "gwt", "GIT_AUTHOR_EMAIL": "gwt@example.com",
+		"GIT_COMMITTER_NAME": "gwt", "GIT_COMMITTER_EMAIL": "gwt@example.com",
+		"GIT_CONFIG_GLOBAL": os.DevNull, "GIT_CONFIG_NOSYSTEM": "1",
+	} {
+		t.Setenv(k, v)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// chdir changes the working directory for the rest of the test; most git
+// helpers in this package work on the current repository.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// testClone returns a clone of a bare remote whose main branch has one commit.
+func testClone(t *testing.T) (clone, remote string) {
+	t.Helper()
+	gitEnv(t)
+	dir := t.TempDir()
+	remote = filepath.Join(dir, "remote.git")
+	clone = filepath.Join(dir, "clone")
+	git(t, dir, "init", "-q", "--bare", "-b", "main", remote)
+	git(t, dir, "clone", "-q", remote, clone)
+	if err := os.WriteFile(filepath.Join(clone, "f"), []byte("base\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, clone, "add", "f")
+	git(t, clone, "commit", "-q", "-m", "base")
+	git(t, clone, "push", "-q", "origin", "main")
+	return clone, remote
+}
+
+// pushPullRequest publishes a commit with subject under ref on remote, the way
+// a forge exposes pull request heads.
+func pushPullRequest(t *testing.T, clone, ref, subject string) string {
+	t.Helper()
+	git(t, clone, "commit", "-q", "--allow-empty", "-m", subject)
+	sha := git(t, clone, "rev-parse", "HEAD")
+	git(t, clone, "push", "-q", "origin", "HEAD:"+ref)
+	git(t, clone, "reset", "-q", "--hard", "HEAD~")
+	return sha
+}
+
+func TestFetchPullRequest(t *testing.T) {
+	clone, _ := testClone(t)
+	github := pushPullRequest(t, clone, "refs/pull/7/head", "Support a..b ranges")
+	gitlab := pushPullRequest(t, clone, "refs/merge-requests/8/head", "Fix login")
+	chdir(t, clone)
+
+	tests := []struct {
+		number  int
+		forge   string
+		sha     string
+		subject string
+	}{
+		{7, "github", github, "Support a..b ranges"},
+		{8, "gitlab", gitlab, "Fix login"},
+	}
+	for _, tt := range tests {
+		head, err := FetchPullRequest("origin", tt.number)
+		if err != nil {
+			t.Fatalf("#%d: %v", tt.number, err)
+		}
+		if head.Forge != tt.forge || head.SHA != tt.sha || head.Subject != tt.subject {
+			t.Errorf("#%d: got %+v, want forge %s, sha %s, subject %q", tt.number, head, tt.forge, tt.sha, tt.subject)
+		}
+	}
+	if _, err := FetchPullRequest("origin", 9); err == nil || !strings.Contains(err.Error(), "pull request #9 not found") {
+		t.Errorf("#9: expected not found, got %v", err)
+	}
+}
+
+func TestPullRequestCheckout(t *testing.T) {
+	clone, _ := testClone(t)
+	sha := pushPullRequest(t, clone, "refs/pull/7/head", "Support a..b ranges")
+	chdir(t, clone)
+
+	head, err := FetchPullRequest("origin", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := PullRequestBranch(head.Number, head.Subject)
+	if branch != "pr/7-support-a-b-ranges" {
+		t.Fatalf("branch = %q", branch)
+	}
+	path := filepath.Join(t.TempDir(), "wt", "repo", branch)
+	if err := CreateAt(CreateSpec{Mode: ModeNew, Name: branch, Branch: branch, Ref: head.SHA}, path); err != nil {
+		t.Fatal(err)
+	}
+	if got := HeadSHA(path); got != sha {
+		t.Errorf("worktree at %s, want %s", got, sha)
+	}
+	if got := FindPullRequestBranch(7); got != branch {
+		t.Errorf("FindPullRequestBranch(7) = %q, want %q", got, branch)
+	}
+}
+
+func TestCreateAtRemovesDirectoriesOnFailure(t *testing.T) {
+	clone, _ := testClone(t)
+	chdir(t, clone)
+
+	root := t.TempDir()
+	path := filepath.Join(root, "repo", "pr", "7-bad")
+	err := CreateAt(CreateSpec{Mode: ModeNew, Name: "bad", Branch: "a..b", Ref: "main"}, path)
+	if err == nil {
+		t.Fatal("expected git worktree add to fail")
+	}
+	if _, err := os.Stat(filepath.Join(root, "repo")); !os.IsNotExist(err) {
+		t.Errorf("%s/repo left behind (%v)", root, err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("pre-existing %s removed: %v", root, err)
+	}
+}
+
+func TestPullRequestBranch(t *testing.T) {
+	tests := []struct {
+		number int
+		slug   string
+		want   string
+	}{
+		{7, "Support a..b ranges", "pr/7-support-a-b-ranges"},
+		{7, "Fix login", "pr/7-fix-login"},
+		{7, "v1.2 release", "pr/7-v1-2-release"},
+		{7, "", "pr/7"},
+		{7, "+++", "pr/7"},
+		{7, "...", "pr/7"},
+		{7, "fix.lock", "pr/7-fix-lock"},
+		{12, "Add a very long subject line that goes on and on well past forty", "pr/12-add-a-very-long-subject-line-that-goes"},
+	}
+	for _, tt := range tests {
+		got := PullRequestBranch(tt.number, tt.slug)
+		if got != tt.want {
+			t.Errorf("PullRequestBranch(%d, %q) = %q, want %q", tt.number, tt.slug, got, tt.want)
+		}
+		if err := ValidateBranchName(got); err != nil {
+			t.Errorf("PullRequestBranch(%d, %q) = %q: %v", tt.number, tt.slug, got, err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nachoal/gwt/internal/registry"
+)
+
+// ProjectIdentity describes where a project's name comes from.
+type ProjectIdentity struct {
+	// Owner is the remote's owner/namespace (e.g. "nachoal"), if known.
+	Owner string
+	// Repo is the repository name from the remote, or the main worktree's
+	// directory name when there is no usable remote.
+	Repo string
+}
+
+// GetProjectIdentity derives owner/repo from the "origin" remote (or the first
+// configured remote). Without remotes it uses the main worktree directory name.
+func GetProjectIdentity() (ProjectIdentity, error) {
+	if url := RemoteURL(); url != "" {
+		owner, repo := parseRemoteURL(url)
+		if repo != "" {
+			return ProjectIdentity{Owner: owner, Repo: repo}, nil
+		}
+	}
+
+	mainWT, err := FindMainWorktree()
+	if err != nil {
+		return ProjectIdentity{}, fmt.Errorf("not in a git repository: %w", err)
+	}
+	name := filepath.Base(mainWT)
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return ProjectIdentity{}, fmt.Errorf("could not determine project name; set settings.project")
+	}
+	return ProjectIdentity{Repo: strings.TrimSuffix(name, ".git")}, nil
+}
+
+// ResolveProjectName picks the directory name used for this repository under
+// root. override (settings.project) always wins. Otherwise the repository name
+// is used unless <root>/<name> already belongs to a different repository, in
+// which case "<owner>-<repo>" is tried before giving up. The bare repository
+// name comes first so that worktrees created before owners were known keep
+// their <root>/<repo> directory.
+func ResolveProjectName(root, override string) (string, error) {
+	if o := strings.TrimSpace(override); o != "" {
+		if strings.ContainsAny(o, `/\`) || o == "." || o == ".." {
+			return "", fmt.Errorf("invalid settings.project %q: must be a single path segment", o)
+		}
+		return o, nil
+	}
+
+	id, err := GetProjectIdentity()
+	if err != nil {
+		return "", err
+	}
+	commonDir, err := CurrentCommonGitDir()
+	if err != nil {
+		return "", err
+	}
+
+	candidates := []string{id.Repo}
+	if id.Owner != "" {
+		candidates = append(candidates, Slug(id.Owner)+"-"+id.Repo)
+	}
+	for _, name := range candidates {
+		other := ProjectOwner(root, name, commonDir)
+		if other == "" {
+			return name, nil
+		}
+		fmt.Fprintf(os.Stderr, "Note: %s is used by another repository (%s)\n", filepath.Join(root, name), other)
+	}
+	return "", fmt.Errorf("project directory %s belongs to another repository; set settings.project to pick a different name", filepath.Join(root, id.Repo))
+}
+
+// ProjectOwner returns the common git dir of a different repository that
+// already has worktrees in <root>/<name>, or "" if the directory is unused or
+// only holds worktrees of commonDir. Repositories that no longer exist do not
+// count as owners.
+func ProjectOwner(root, name, commonDir string) string {
+	projDir := filepath.Join(root, name)
+
+	owners := map[string]bool{}
+	if reg, err := registry.Load(root); err == nil {
+		for _, e := range reg.Worktrees {
+			if e.Project == name && e.CommonDir != "" {
+				owners[e.CommonDir] = true
+			}
+		}
+	}
+	for _, dir := range worktreeDirsIn(projDir) {
+		if c := CommonDirFromGitFile(dir); c != "" {
+			owners[c] = true
+		}
+	}
+
+	for owner := range owners {
+		if SamePath(owner, commonDir) {
+			continue
+		}
+		if _, err := os.Stat(owner); err != nil {
+			continue
+		}
+		return owner
+	}
+	return ""
+}
+
+// CommonDirFromGitFile reads a linked worktree's ".git" file and returns the
+// common git dir it points to, without running git. It returns "" if dir is
+// not a linked worktree.
+func CommonDirFromGitFile(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return ""
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return ""
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+
+	// <common>/worktrees/<id> carries a "commondir" file, usually "../..".
+	common := filepath.Join(gitDir, "..", "..")
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+	}
+	return filepath.Clean(common)
+}
+
+// RemoteURL returns the URL of the "origin" remote, or of the first configured
+// remote, or "" if there are none.
+func RemoteURL() string {
+	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	// No origin: use the first configured remote, if any.
+	out, err = exec.Command("git", "remote").Output()
+	if err != nil {
+		return ""
+	}
+	for _, name := range strings.Fields(string(out)) {
+		if out, err := exec.Command("git", "remote", "get-url", name).Output(); err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	}
+	return ""
+}
+
+// parseRemoteURL extracts owner and repo from remote URLs such as
+// git@github.com:owner/repo.git, https://host/owner/repo and /srv/git/repo.git.
+func parseRemoteURL(url string) (owner, repo string) {
+	url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		// Drop host[:port]
+		if j := strings.Index(url, "/"); j >= 0 {
+			url = url[j+1:]
+		} else {
+			url = ""
+		}
+	} else if i := strings.Index(url, ":"); i >= 0 && !strings.HasPrefix(url, "/") && !strings.Contains(url[:i], "/") {
+		// scp-like syntax: [user@]host:owner/repo
+		url = url[i+1:]
+	}
+
+	parts := strings.Split(url, "/")
+	repo = strings.TrimSuffix(parts[len(parts)-1], ".git")
+	if len(parts) >= 2 {
+		owner = parts[len(parts)-2]
+	}
+	return owner, repo
+}
+
+// SamePath reports whether a and b name the same file or directory once
+// symlinks are resolved.
+func SamePath(a, b string) bool {
+	if ra, err := filepath.EvalSymlinks(a); err == nil {
+		a = ra
+	}
+	if rb, err := filepath.EvalSymlinks(b); err == nil {
+		b = rb
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
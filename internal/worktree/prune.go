@@ -0,0 +1,199 @@
+package worktree
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nachoal/gwt/internal/registry"
+)
+
+// Kinds of leftovers found by FindLeftovers.
+const (
+	// LeftoverOrphan is a worktree whose repository no longer exists.
+	LeftoverOrphan = "orphan"
+	// LeftoverEmpty is a directory tree without any files, e.g. a project
+	// directory whose worktrees were all removed.
+	LeftoverEmpty = "empty"
+	// LeftoverPartial is a directory with files but no worktree, e.g. what a
+	// failed creation or a manual `rm -rf .git` left behind.
+	LeftoverPartial = "partial"
+	// LeftoverUnreadable is a worktree whose .git file cannot be read, e.g.
+	// after a permissions problem. It is reported but never deleted.
+	LeftoverUnreadable = "unreadable"
+)
+
+// leftoverGrace protects directories a concurrent `gwt new` may be filling.
+const leftoverGrace = time.Minute
+
+// Leftover is a directory under the root that no repository uses.
+type Leftover struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+	// Size is the total size of the files below Path, in bytes.
+	Size int64 `json:"size"`
+	// Kept says why prune leaves Path alone; it is empty for leftovers that
+	// may be deleted.
+	Kept string `json:"kept,omitempty"`
+}
+
+// FindLeftovers walks the project directories under root and returns
+// orphaned worktrees, empty directories and directories holding files but no
+// worktree, outermost first. Clones (.git directories) and healthy worktrees
+// are never reported, and neither is anything changed in the last minute.
+//
+// Orphans and directories with files are only offered for deletion when gwt
+// created them: the registry records them or they match pathTemplate. Others,
+// such as notes, a shared cache or a checkout kept under the root, and
+// unreadable worktrees are reported with Kept set.
+func FindLeftovers(root, pathTemplate string) []Leftover {
+	var found []Leftover
+	projects, _ := os.ReadDir(root)
+	for _, p := range projects {
+		if !p.IsDir() || strings.HasPrefix(p.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(root, p.Name())
+		if used, files := scanLeftovers(dir, &found); !used {
+			found = append(found, newLeftover(dir, files))
+		}
+	}
+	registered := make(map[string]bool)
+	if reg, err := registry.Load(root); err == nil {
+		for _, e := range reg.Worktrees {
+			registered[filepath.Clean(e.Path)] = true
+		}
+	}
+	kept := found[:0]
+	for _, l := range found {
+		if fi, err := os.Stat(l.Path); err == nil && time.Since(fi.ModTime()) < leftoverGrace {
+			continue
+		}
+		if !registered[l.Path] && !matchesPathTemplate(pathTemplate, root, l.Path) {
+			switch l.Kind {
+			case LeftoverPartial:
+				l.Kept = "not created by gwt"
+			case LeftoverOrphan:
+				// The repository may only be moved or on an unmounted drive.
+				l.Kept = "not created by gwt; if its repository moved, run 'gwt doctor --fix=links' from it"
+			}
+		}
+		l.Size = dirSize(l.Path)
+		kept = append(kept, l)
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].Path < kept[j].Path })
+	return kept
+}
+
+// scanLeftovers reports whether dir holds a worktree or clone (used) and
+// whether it holds any files. Unused subtrees of a used dir are added to
+// found; an unused dir is left for its parent to report.
+func scanLeftovers(dir string, found *[]Leftover) (used, files bool) {
+	if info, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+		if info.IsDir() {
+			return true, true
+		}
+		switch state, gitDir := CheckLink(dir); state {
+		case LinkMissing:
+			*found = append(*found, Leftover{Path: dir, Kind: LeftoverOrphan, Detail: "repository " + strings.TrimSuffix(filepath.Dir(filepath.Dir(gitDir)), string(os.PathSeparator)) + " is gone"})
+		case LinkInvalid:
+			*found = append(*found, Leftover{Path: dir, Kind: LeftoverUnreadable, Detail: "unreadable .git file", Kept: "fix or remove it by hand"})
+		}
+		return true, true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// Unreadable: treat as used so it is never deleted.
+		return true, true
+	}
+	type child struct {
+		path  string
+		files bool
+	}
+	var unused []child
+	for _, e := range entries {
+		if !e.IsDir() || e.Type()&fs.ModeSymlink != 0 {
+			files = true
+			continue
+		}
+		sub := filepath.Join(dir, e.Name())
+		subUsed, subFiles := scanLeftovers(sub, found)
+		files = files || subFiles
+		if subUsed {
+			used = true
+		} else {
+			unused = append(unused, child{sub, subFiles})
+		}
+	}
+	if used {
+		for _, c := range unused {
+			*found = append(*found, newLeftover(c.path, c.files))
+		}
+	}
+	return used, files
+}
+
+func newLeftover(path string, files bool) Leftover {
+	if files {
+		return Leftover{Path: path, Kind: LeftoverPartial, Detail: "files but no worktree"}
+	}
+	return Leftover{Path: path, Kind: LeftoverEmpty}
+}
+
+// branchPlaceholder stands in for the branch when matching paths against the
+// path template; slug leaves it unchanged.
+const branchPlaceholder = "gwtbranchplaceholder"
+
+// matchesPathTemplate reports whether path is where pathTemplate puts some
+// branch of the project named by path's first directory under root.
+func matchesPathTemplate(pathTemplate, root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	project := strings.SplitN(rel, string(os.PathSeparator), 2)[0]
+	rendered, err := RenderWorktreePath(pathTemplate, root, project, branchPlaceholder)
+	if err != nil || !strings.Contains(rendered, branchPlaceholder) {
+		return false
+	}
+	pattern := strings.Replace(regexp.QuoteMeta(filepath.Clean(rendered)), branchPlaceholder, ".+", 1)
+	ok, _ := regexp.MatchString("^"+pattern+"$", path)
+	return ok
+}
+
+// RemoveLeftover deletes l and then any directories it leaves empty, up to
+// but excluding root.
+func RemoveLeftover(root string, l Leftover) error {
+	if err := os.RemoveAll(l.Path); err != nil {
+		return err
+	}
+	root = filepath.Clean(root)
+	for dir := filepath.Dir(l.Path); dir != root && strings.HasPrefix(dir, root+string(os.PathSeparator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func dirSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
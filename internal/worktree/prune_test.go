@@ -0,0 +1,134 @@
+package worktree
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nachoal/gwt/internal/registry"
+)
+
+// leftoverRoot lays out a root with one of each kind of leftover, all older
+// than the grace period except repo/fresh.
+func leftoverRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mkdir := func(name string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mkdir("repo/clone/.git")
+	write("repo/half/file", "x")
+	write("repo/orphan/.git", "gitdir: /nonexistent/repo/.git/worktrees/orphan\n")
+	write("repo/bad/.git", "garbage\n")
+	write("repo/fresh/file", "x")
+	write("notes/todo.txt", "x")
+	write("moved/.git", "gitdir: /nonexistent/moved/.git/worktrees/moved\n")
+	write("registered/.git", "gitdir: /nonexistent/registered/.git/worktrees/registered\n")
+	mkdir("emptyproj/sub")
+	if err := registry.Register(root, registry.Entry{Path: filepath.Join(root, "registered")}); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Base(path) == "fresh" {
+			return filepath.SkipDir
+		}
+		return os.Chtimes(path, old, old)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestFindLeftovers(t *testing.T) {
+	type item struct{ kind, kept string }
+	const notCreated = "not created by gwt"
+	const movedHint = "not created by gwt; if its repository moved, run 'gwt doctor --fix=links' from it"
+
+	tests := []struct {
+		name     string
+		template string
+		want     map[string]item
+	}{
+		{
+			name: "default template",
+			want: map[string]item{
+				"emptyproj":   {LeftoverEmpty, ""},
+				"moved":       {LeftoverOrphan, movedHint},
+				"notes":       {LeftoverPartial, notCreated},
+				"registered":  {LeftoverOrphan, ""},
+				"repo/bad":    {LeftoverUnreadable, "fix or remove it by hand"},
+				"repo/half":   {LeftoverPartial, ""},
+				"repo/orphan": {LeftoverOrphan, ""},
+			},
+		},
+		{
+			name:     "flat template",
+			template: "{{.Root}}/{{.Project}}-{{slug .Branch}}",
+			want: map[string]item{
+				"emptyproj":   {LeftoverEmpty, ""},
+				"moved":       {LeftoverOrphan, movedHint},
+				"notes":       {LeftoverPartial, notCreated},
+				"registered":  {LeftoverOrphan, ""},
+				"repo/bad":    {LeftoverUnreadable, "fix or remove it by hand"},
+				"repo/half":   {LeftoverPartial, notCreated},
+				"repo/orphan": {LeftoverOrphan, movedHint},
+			},
+		},
+	}
+	for _, tt := range tests {
+		root := leftoverRoot(t)
+		got := map[string]item{}
+		for _, l := range FindLeftovers(root, tt.template) {
+			rel, _ := filepath.Rel(root, l.Path)
+			got[filepath.ToSlash(rel)] = item{l.Kind, l.Kept}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %v\nwant %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveLeftover(t *testing.T) {
+	root := leftoverRoot(t)
+	for _, l := range FindLeftovers(root, "") {
+		if l.Kept != "" {
+			continue
+		}
+		if err := RemoveLeftover(root, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"repo/clone/.git", "repo/bad/.git", "repo/fresh/file", "notes/todo.txt", "moved/.git"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+	for _, name := range []string{"repo/half", "repo/orphan", "registered", "emptyproj"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists (%v)", name, err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/nachoal/gwt/internal/config"
+	"github.com/nachoal/gwt/internal/registry"
 )
 
 // RootItem represents a worktree discovered under the configured root.
@@ -16,101 +17,88 @@ type RootItem struct {
 	Branch  string `json:"branch"`
 	Path    string `json:"path"`
 	Head    string `json:"head"`
+	// Orphaned marks worktrees whose repository no longer exists; see
+	// FindLeftovers.
+	Orphaned bool `json:"orphaned,omitempty"`
 }
 
-// ListFromRoot scans the gwt root (or override) for worktrees and returns a flat list.
-// It treats a directory as a worktree iff the entry ".git" exists and is a file
-// (not a directory), which is how git worktrees are represented.
-// It also attempts to read the current branch and HEAD short SHA via git.
-func ListFromRoot(override string) ([]RootItem, string, error) {
+// ResolveRoot returns the absolute gwt root: override, with a leading ~/
+// expanded, or settings.root.
+func ResolveRoot(override string) (string, error) {
 	root := override
 	if root == "" {
 		cfg, err := config.LoadConfig()
 		if err != nil {
-			return nil, "", err
+			return "", err
 		}
 		root = cfg.Settings.Root
-	} else {
-		// Expand leading ~/
-		if strings.HasPrefix(root, "~/") {
-			if home, err := os.UserHomeDir(); err == nil {
-				root = filepath.Join(home, root[2:])
-			}
+	} else if strings.HasPrefix(root, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			root = filepath.Join(home, root[2:])
 		}
 	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return root, nil
+}
 
-	absRoot, err := filepath.Abs(root)
-	if err == nil {
-		root = absRoot
+// ListFromRoot returns the worktrees recorded in the root registry plus any
+// others found by scanning the gwt root (or override).
+// The scan treats a directory as a worktree iff the entry ".git" exists and is a file
+// (not a directory), which is how git worktrees are represented.
+// It also attempts to read the current branch and HEAD short SHA via git.
+func ListFromRoot(override string) ([]RootItem, string, error) {
+	root, err := ResolveRoot(override)
+	if err != nil {
+		return nil, "", err
 	}
 
 	if fi, err := os.Stat(root); err != nil || !fi.IsDir() {
 		return []RootItem{}, root, nil
 	}
 
-	projects, _ := os.ReadDir(root)
 	var items []RootItem
+	seen := make(map[string]bool)
 
-	for _, p := range projects {
-		if !p.IsDir() {
-			continue
-		}
-		projPath := filepath.Join(root, p.Name())
-		// BFS/stack to find leaf dirs that are worktrees (detect .git file at top level)
-		stack := []string{projPath}
-		for len(stack) > 0 {
-			// pop
-			d := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-
-			// Check for worktree marker: .git file (not directory)
-			gitPath := filepath.Join(d, ".git")
-			if info, err := os.Lstat(gitPath); err == nil {
-				if !info.IsDir() { // file => worktree
-					// Collect details
-					branch := readBranch(d)
-					if branch == "HEAD" || branch == "" {
-						// Fallback from path (relative to project)
-						rel := strings.TrimPrefix(d, projPath+string(os.PathSeparator))
-						branch = filepath.ToSlash(rel)
-					}
-					head := readHead(d)
-					items = append(items, RootItem{
-						Project: p.Name(),
-						Branch:  branch,
-						Path:    d,
-						Head:    head,
-					})
-					continue // do not descend into a worktree
-				}
-				// If .git is a directory, it's a full repo clone; skip descending
-				continue
+	// Worktrees recorded at creation time are listed whatever their layout.
+	if reg, err := registry.Load(root); err == nil {
+		for _, e := range reg.Worktrees {
+			branch := readBranch(e.Path)
+			if branch == "HEAD" || branch == "" {
+				branch = e.Branch
 			}
+			items = append(items, RootItem{
+				Project:  e.Project,
+				Branch:   branch,
+				Path:     e.Path,
+				Head:     readHead(e.Path),
+				Orphaned: orphaned(e.Path),
+			})
+			seen[e.Path] = true
+		}
+	}
 
-			// .git not present here; descend into subdirectories
-			entries, err := os.ReadDir(d)
-			if err != nil {
-				continue
-			}
-			for _, e := range entries {
-				if !e.IsDir() {
-					continue
-				}
-				name := e.Name()
-				if strings.HasPrefix(name, ".") { // skip hidden dirs
-					continue
-				}
-				// Skip common heavy dirs if ever encountered before we hit a worktree (defensive)
-				if name == "node_modules" || name == "vendor" || name == "dist" || name == "build" {
-					continue
-				}
-				// Do not follow symlinks
-				if info, err := e.Info(); err == nil && (info.Mode()&os.ModeSymlink) != 0 {
-					continue
-				}
-				stack = append(stack, filepath.Join(d, name))
-			}
+	// Fall back to scanning for worktrees created before the registry existed.
+	for _, d := range WorktreeDirs(root) {
+		if seen[d] {
+			continue
+		}
+		rel, _ := filepath.Rel(root, d)
+		project := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+		branch := readBranch(d)
+		if branch == "HEAD" || branch == "" {
+			// Fallback from path (relative to project)
+			projPath := filepath.Join(root, project)
+			branch = filepath.ToSlash(strings.TrimPrefix(d, projPath+string(os.PathSeparator)))
 		}
+		items = append(items, RootItem{
+			Project:  project,
+			Branch:   branch,
+			Path:     d,
+			Head:     readHead(d),
+			Orphaned: orphaned(d),
+		})
 	}
 
 	// Sort by project then branch
@@ -124,6 +112,11 @@ func ListFromRoot(override string) ([]RootItem, string, error) {
 	return items, root, nil
 }
 
+func orphaned(dir string) bool {
+	state, _ := CheckLink(dir)
+	return state == LinkMissing || state == LinkInvalid
+}
+
 func readBranch(dir string) string {
 	// git rev-parse --abbrev-ref HEAD
 	cmd := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD")
@@ -142,3 +135,63 @@ func readHead(dir string) string {
 	}
 	return strings.TrimSpace(string(out))
 }
+
+// WorktreeDirs finds the worktree directories below root's project
+// directories (see worktreeDirsIn), skipping hidden ones such as .gwt.
+func WorktreeDirs(root string) []string {
+	var dirs []string
+	projects, _ := os.ReadDir(root)
+	for _, p := range projects {
+		if !p.IsDir() || strings.HasPrefix(p.Name(), ".") {
+			continue
+		}
+		dirs = append(dirs, worktreeDirsIn(filepath.Join(root, p.Name()))...)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// worktreeDirsIn finds the worktree directories at or below dir. A directory
+// is a worktree iff it has a ".git" file (not a directory); the search does
+// not descend into worktrees, clones, hidden or dependency directories, or
+// follow symlinks.
+func worktreeDirsIn(dir string) []string {
+	var dirs []string
+	stack := []string{dir}
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if info, err := os.Lstat(filepath.Join(d, ".git")); err == nil {
+			if !info.IsDir() { // file => worktree
+				dirs = append(dirs, d)
+			}
+			// If .git is a directory, it's a full repo clone; skip descending
+			continue
+		}
+
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			name := e.Name()
+			if strings.HasPrefix(name, ".") { // skip hidden dirs
+				continue
+			}
+			// Skip common heavy dirs if ever encountered before we hit a worktree (defensive)
+			if name == "node_modules" || name == "vendor" || name == "dist" || name == "build" {
+				continue
+			}
+			// Do not follow symlinks
+			if info, err := e.Info(); err == nil && (info.Mode()&os.ModeSymlink) != 0 {
+				continue
+			}
+			stack = append(stack, filepath.Join(d, name))
+		}
+	}
+	return dirs
+}
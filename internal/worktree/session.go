@@ -0,0 +1,46 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/nachoal/gwt/internal/session"
+)
+
+// SessionName names the session of a worktree after the project and branch,
+// e.g. "api" and "feature/foo" give "api-feature-foo": the Slug, with the '.'
+// that tmux reserves replaced as well.
+func SessionName(project, branch string) string {
+	return collapseDashes(strings.ReplaceAll(Slug(project+"-"+branch), ".", "-"))
+}
+
+// collapseDashes turns runs of '-' into one and drops leading and trailing ones.
+func collapseDashes(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == '-' }), "-")
+}
+
+// Session returns the multiplexer session recorded for the worktree at path, or nil.
+func Session(path string) *session.Session {
+	m, err := ReadMetadata(path)
+	if err != nil || m == nil {
+		return nil
+	}
+	return m.Session
+}
+
+// killSession ends the session of a removed worktree. Failures are reported
+// but do not fail the removal.
+func killSession(s *session.Session) {
+	if s == nil {
+		return
+	}
+	// gwt may run inside the session; survive its hangup to finish the
+	// command (branch deletion, journal).
+	signal.Ignore(syscall.SIGHUP)
+	if err := s.Kill(); err != nil {
+		fmt.Fprintf(os.Stderr, "Note: could not end %s session %s: %v\n", s.Multiplexer, s.Name, err)
+	}
+}
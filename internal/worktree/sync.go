@@ -0,0 +1,149 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Sync outcomes reported in SyncResult.Status.
+const (
+	SyncRebased  = "rebased"
+	SyncMerged   = "merged"
+	SyncUpToDate = "up-to-date"
+	SyncSkipped  = "skipped"
+	SyncConflict = "conflict"
+	SyncFailed   = "failed"
+)
+
+// SyncOptions controls how Sync brings a worktree up to date.
+type SyncOptions struct {
+	// Merge merges the base instead of rebasing onto it.
+	Merge bool
+	// Autostash stashes local changes around the operation instead of
+	// skipping dirty worktrees.
+	Autostash bool
+}
+
+// SyncResult describes what Sync did to one worktree.
+type SyncResult struct {
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+	Base   string `json:"base"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// FetchAll fetches every remote once. Repositories without remotes are left alone.
+func FetchAll() error {
+	if len(remotes()) == 0 {
+		return nil
+	}
+	output, err := exec.Command("git", "fetch", "--all", "--quiet").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git fetch --all failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SyncTarget returns the ref a branch based on base is synced onto: the base's
+// upstream (e.g. origin/main) when it has one, so a stale local base does not
+// hold branches back, else base itself.
+func SyncTarget(base string) string {
+	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", base+"@{upstream}").Output()
+	if err == nil && strings.TrimSpace(string(out)) != "" {
+		return strings.TrimSpace(string(out))
+	}
+	return base
+}
+
+// Sync rebases the branch checked out in wt onto target (or merges target
+// into it). Dirty worktrees are skipped unless opts.Autostash is set. If the
+// operation stops on conflicts it is aborted, so the worktree is left exactly
+// as it was and reported as SyncConflict.
+func Sync(wt Worktree, base, target string, opts SyncOptions) SyncResult {
+	head := HeadSHA(wt.Path)
+	res := SyncResult{Branch: wt.Branch, Path: wt.Path, Base: base, Before: head, After: head}
+	skip := func(detail string) SyncResult {
+		res.Status, res.Detail = SyncSkipped, detail
+		return res
+	}
+
+	if wt.Branch == "" {
+		return skip("detached HEAD")
+	}
+	if op := operationInProgress(wt.Path); op != "" {
+		return skip(op + " in progress")
+	}
+	if !opts.Autostash && isDirty(wt.Path) {
+		return skip("uncommitted changes (use --autostash)")
+	}
+	if exec.Command("git", "-C", wt.Path, "merge-base", "--is-ancestor", target, "HEAD").Run() == nil {
+		res.Status = SyncUpToDate
+		return res
+	}
+
+	args := []string{"-C", wt.Path, "rebase"}
+	status, abort := SyncRebased, "rebase"
+	if opts.Merge {
+		args = []string{"-C", wt.Path, "merge", "--no-edit"}
+		status, abort = SyncMerged, "merge"
+	}
+	if opts.Autostash {
+		args = append(args, "--autostash")
+	}
+	args = append(args, target)
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		if operationInProgress(wt.Path) != "" {
+			_ = exec.Command("git", "-C", wt.Path, abort, "--abort").Run()
+			res.Status = SyncConflict
+			res.Detail = fmt.Sprintf("conflicts with %s; %s aborted, worktree unchanged", target, abort)
+		} else {
+			res.Status = SyncFailed
+			res.Detail = lastLine(string(output))
+		}
+		res.After = HeadSHA(wt.Path)
+		return res
+	}
+	res.Status, res.After = status, HeadSHA(wt.Path)
+	return res
+}
+
+// isDirty reports tracked changes; untracked files do not block a rebase.
+func isDirty(path string) bool {
+	out, err := exec.Command("git", "-C", path, "status", "--porcelain", "--untracked-files=no").Output()
+	return err != nil || strings.TrimSpace(string(out)) != ""
+}
+
+// operationInProgress names an unfinished rebase or merge in the worktree at path.
+func operationInProgress(path string) string {
+	for _, p := range []struct{ name, op string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+	} {
+		out, err := exec.Command("git", "-C", path, "rev-parse", "--git-path", p.name).Output()
+		if err != nil {
+			continue
+		}
+		gitPath := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(gitPath) {
+			gitPath = filepath.Join(path, gitPath)
+		}
+		if _, err := os.Stat(gitPath); err == nil {
+			return p.op
+		}
+	}
+	return ""
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,97 @@
+package worktree
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data available to files in the templates: config.
+type TemplateData struct {
+	// Branch is the worktree's branch ("" when detached); BranchSlug is
+	// Slug(Name), safe for database names, compose projects and hostnames.
+	Branch     string
+	BranchSlug string
+	// Name is the branch or ref the worktree was created for.
+	Name    string
+	Path    string
+	Project string
+	// Base is the recorded base branch, or "".
+	Base string
+	// Ports are the reserved ports by name (see the ports: config), e.g. {{.Ports.web}}.
+	Ports    map[string]int
+	MainPath string
+}
+
+// NewTemplateData collects the template variables for a worktree created from spec.
+func NewTemplateData(spec CreateSpec, project, path, mainPath string, ports map[string]int) TemplateData {
+	data := TemplateData{
+		Branch:     spec.Branch,
+		BranchSlug: Slug(spec.Name),
+		Name:       spec.Name,
+		Path:       path,
+		Project:    project,
+		Ports:      ports,
+		MainPath:   mainPath,
+	}
+	if spec.Mode == ModeNew {
+		data.Base = baseBranchName(spec.Ref)
+	}
+	if data.Ports == nil {
+		data.Ports = map[string]int{}
+	}
+	return data
+}
+
+// RenderTemplates renders each source template (relative to srcRoot) with
+// text/template and writes it to its destination (relative to destRoot),
+// replacing any existing file. Destinations are written in sorted order.
+func RenderTemplates(srcRoot, destRoot string, templates map[string]string, data TemplateData) error {
+	dests := make([]string, 0, len(templates))
+	srcFor := make(map[string]string, len(templates))
+	for src, dest := range templates {
+		dests = append(dests, dest)
+		srcFor[dest] = src
+	}
+	sort.Strings(dests)
+
+	for _, dest := range dests {
+		src := srcFor[dest]
+		if filepath.IsAbs(dest) || strings.HasPrefix(filepath.Clean(dest), "..") {
+			return fmt.Errorf("template destination %q must be inside the worktree", dest)
+		}
+		srcPath := src
+		if !filepath.IsAbs(srcPath) {
+			srcPath = filepath.Join(srcRoot, src)
+		}
+		info, err := os.Stat(srcPath)
+		if err != nil {
+			return fmt.Errorf("template %s: %w", src, err)
+		}
+		raw, err := os.ReadFile(srcPath)
+		if err != nil {
+			return fmt.Errorf("template %s: %w", src, err)
+		}
+		t, err := template.New(src).Funcs(pathFuncs).Option("missingkey=error").Parse(string(raw))
+		if err != nil {
+			return fmt.Errorf("template %s: %w", src, err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return fmt.Errorf("template %s: %w", src, err)
+		}
+
+		destPath := filepath.Join(destRoot, dest)
+		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(destPath), err)
+		}
+		if err := os.WriteFile(destPath, buf.Bytes(), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,29 +10,21 @@ import (
 	"time"
 )
 
+// Worktree is an entry of `git worktree list --porcelain`.
 type Worktree struct {
-	Path   string `json:"path"`
-	Branch string `json:"branch"`
-	Head   string `json:"head"`
-}
-
-func GetProjectName() (string, error) {
-	cmd := exec.Command("git", "remote", "get-url", "origin")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get remote URL: %w", err)
-	}
-
-	url := strings.TrimSpace(string(output))
-	// Extract project name from URL
-	parts := strings.Split(url, "/")
-	if len(parts) == 0 {
-		return "", fmt.Errorf("invalid remote URL")
-	}
-
-	projectName := parts[len(parts)-1]
-	projectName = strings.TrimSuffix(projectName, ".git")
-	return projectName, nil
+	Path string `json:"path"`
+	// Branch is empty for detached and bare entries.
+	Branch   string `json:"branch"`
+	Head     string `json:"head"`
+	Detached bool   `json:"detached"`
+	// Bare marks the repository entry of a bare clone, which is not a worktree.
+	Bare bool `json:"bare"`
+	// Locked is set by `git worktree lock`; LockReason is its optional reason.
+	Locked     bool   `json:"locked"`
+	LockReason string `json:"lock_reason,omitempty"`
+	// Prunable worktrees are stale registrations `git worktree prune` would drop.
+	Prunable       bool   `json:"prunable"`
+	PrunableReason string `json:"prunable_reason,omitempty"`
 }
 
 func GetDefaultBranch() (string, error) {
@@ -61,28 +53,57 @@ func GetDefaultBranch() (string, error) {
 	return "main", nil
 }
 
-func GetWorktreePath(root, projectName, branchName string) string {
-	return filepath.Join(root, projectName, branchName)
+// Create adds a worktree for branchName at targetPath, checking out the branch
+// if it exists locally or creating it from fromBranch otherwise.
+func Create(branchName, fromBranch, targetPath string) error {
+	spec := CreateSpec{Mode: ModeNew, Name: branchName, Branch: branchName, Ref: fromBranch}
+	if localBranchExists(branchName) {
+		spec = CreateSpec{Mode: ModeExisting, Name: branchName, Branch: branchName, Ref: branchName}
+	}
+	return CreateAt(spec, targetPath)
 }
 
-func Create(branchName, fromBranch, targetPath string) error {
+// CreateAt creates targetPath's parent directory and adds the worktree
+// described by spec there. If git fails, the directories it created are
+// removed again.
+func CreateAt(spec CreateSpec, targetPath string) error {
+	// Distinct branches can map to the same directory (e.g. via the slug
+	// template function); never let git reuse an existing path.
+	if _, err := os.Stat(targetPath); err == nil {
+		return fmt.Errorf("target path already exists: %s", targetPath)
+	}
+
 	// Create parent directory if it doesn't exist
 	parentDir := filepath.Dir(targetPath)
+	existing := parentDir
+	for {
+		if _, err := os.Stat(existing); err == nil || filepath.Dir(existing) == existing {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
 		return fmt.Errorf("failed to create parent directory: %w", err)
 	}
-
-	// Check if branch exists
-	checkCmd := exec.Command("git", "rev-parse", "--verify", branchName)
-	if err := checkCmd.Run(); err == nil {
-		// Branch exists, create worktree from it
-		cmd := exec.Command("git", "worktree", "add", targetPath, branchName)
-		return cmd.Run()
+	if err := CreateFromSpec(spec, targetPath); err != nil {
+		// os.Remove only removes empty directories, so nothing of the user's goes.
+		for dir := targetPath; dir != existing && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+				break
+			}
+		}
+		return err
 	}
+	return nil
+}
 
-	// Create new branch from base
-	cmd := exec.Command("git", "worktree", "add", "-b", branchName, targetPath, fromBranch)
-	return cmd.Run()
+// HeadSHA returns the full SHA of HEAD in the worktree at dir, or "".
+func HeadSHA(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
 }
 
 // FindMainWorktree returns the path to the main worktree (the original clone).
@@ -90,6 +111,19 @@ func Create(branchName, fromBranch, targetPath string) error {
 func FindMainWorktree() (string, error) {
 	// git rev-parse --git-common-dir gives the shared .git dir.
 	// From that we can derive the main worktree root.
+	commonDir, err := CurrentCommonGitDir()
+	if err != nil {
+		return "", err
+	}
+	// The common dir is typically <main-worktree>/.git
+	// filepath.Dir gives us the main worktree root.
+	mainWorktree := filepath.Dir(commonDir)
+	return mainWorktree, nil
+}
+
+// CurrentCommonGitDir returns the absolute common git directory of the
+// repository containing the current working directory.
+func CurrentCommonGitDir() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--git-common-dir")
 	out, err := cmd.Output()
 	if err != nil {
@@ -100,53 +134,174 @@ func FindMainWorktree() (string, error) {
 		cwd, _ := os.Getwd()
 		commonDir = filepath.Join(cwd, commonDir)
 	}
-	// The common dir is typically <main-worktree>/.git
-	// filepath.Dir gives us the main worktree root.
-	mainWorktree := filepath.Dir(commonDir)
-	return mainWorktree, nil
+	return filepath.Clean(commonDir), nil
 }
 
+// BranchSHA returns the full SHA that refs/heads/<branch> points to in the
+// given common git dir, or "" if the branch does not exist.
+func BranchSHA(commonGitDir, branch string) string {
+	if branch == "" || commonGitDir == "" {
+		return ""
+	}
+	cmd := exec.Command("git", "--git-dir", commonGitDir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// List returns the worktrees of the current repository, main worktree first.
+// The entry of a bare repository is left out; see ListAll.
 func List() ([]Worktree, error) {
-	// Discover the main worktree so the command works from any worktree
-	// or even when a worktree's state is broken.
-	mainWT, err := FindMainWorktree()
+	all, err := ListAll()
+	if err != nil {
+		return nil, err
+	}
+	worktrees := all[:0]
+	for _, wt := range all {
+		if !wt.Bare {
+			worktrees = append(worktrees, wt)
+		}
+	}
+	return worktrees, nil
+}
+
+// ListAll returns every entry of `git worktree list`, including a bare
+// repository.
+func ListAll() ([]Worktree, error) {
+	// Run in the common git dir so the command works from any worktree, when
+	// a worktree's state is broken, and in bare repositories.
+	commonDir, err := CurrentCommonGitDir()
 	if err != nil {
 		return nil, fmt.Errorf("not in a git repository: %w", err)
 	}
-	cmd := exec.Command("git", "-C", mainWT, "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
+	// -z (git 2.36+) keeps paths containing newlines intact.
+	output, err := exec.Command("git", "-C", commonDir, "worktree", "list", "--porcelain", "-z").Output()
+	if err == nil {
+		return parsePorcelain(string(output), "\x00"), nil
+	}
+	output, err = exec.Command("git", "-C", commonDir, "worktree", "list", "--porcelain").Output()
 	if err != nil {
 		return nil, err
 	}
+	return parsePorcelain(string(output), "\n"), nil
+}
 
+// parsePorcelain parses `git worktree list --porcelain` output whose fields
+// end with sep. An empty field ends an entry.
+func parsePorcelain(output, sep string) []Worktree {
 	var worktrees []Worktree
-	lines := strings.Split(string(output), "\n")
-
 	var current Worktree
-	for _, line := range lines {
-		if strings.HasPrefix(line, "worktree ") {
-			if current.Path != "" {
-				worktrees = append(worktrees, current)
-			}
-			current = Worktree{Path: strings.TrimPrefix(line, "worktree ")}
-		} else if strings.HasPrefix(line, "branch ") {
-			current.Branch = strings.TrimPrefix(line, "branch refs/heads/")
-		} else if strings.HasPrefix(line, "HEAD ") {
-			current.Head = strings.TrimPrefix(line, "HEAD ")
+	flush := func() {
+		if current.Path != "" {
+			worktrees = append(worktrees, current)
 		}
+		current = Worktree{}
 	}
-	if current.Path != "" {
-		worktrees = append(worktrees, current)
+	for _, field := range strings.Split(output, sep) {
+		key, value, _ := strings.Cut(field, " ")
+		switch key {
+		case "":
+			flush()
+		case "worktree":
+			flush()
+			current.Path = value
+		case "HEAD":
+			current.Head = value
+		case "branch":
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "detached":
+			current.Detached = true
+		case "bare":
+			current.Bare = true
+		case "locked":
+			current.Locked = true
+			current.LockReason = value
+		case "prunable":
+			current.Prunable = true
+			current.PrunableReason = value
+		}
 	}
+	flush()
+	return worktrees
+}
 
-	return worktrees, nil
+// Name is how commands refer to the worktree: its branch, or the directory
+// name of a detached worktree.
+func (wt Worktree) Name() string {
+	if wt.Branch != "" {
+		return wt.Branch
+	}
+	return filepath.Base(wt.Path)
+}
+
+// NotFoundError is returned by Find when no worktree matches.
+type NotFoundError struct {
+	Name string
 }
 
-func Remove(path string, force bool) error {
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("worktree for branch '%s' not found", e.Name)
+}
+
+// Find returns the worktree that name refers to: a branch, a worktree path,
+// or the directory name of a detached worktree. A directory name shared by
+// several detached worktrees is an error; their paths tell them apart.
+func Find(worktrees []Worktree, name string) (Worktree, error) {
+	for _, wt := range worktrees {
+		if wt.Branch == name {
+			return wt, nil
+		}
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		for _, wt := range worktrees {
+			if SamePath(wt.Path, abs) {
+				return wt, nil
+			}
+		}
+	}
+	var matches []Worktree
+	for _, wt := range worktrees {
+		if wt.Branch == "" && filepath.Base(wt.Path) == name {
+			matches = append(matches, wt)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Worktree{}, &NotFoundError{Name: name}
+	case 1:
+		return matches[0], nil
+	}
+	paths := make([]string, len(matches))
+	for i, wt := range matches {
+		paths[i] = wt.Path
+	}
+	return Worktree{}, fmt.Errorf("'%s' names several detached worktrees (%s); pass a path instead", name, strings.Join(paths, ", "))
+}
+
+// Remove removes the worktree at path with `git worktree remove` and releases
+// its entry in the registry under root ("" skips the registry).
+func Remove(root, path string, force bool) error {
 	// Run from the main worktree so that removing the current worktree
 	// (i.e. the cwd) does not fail because git can't remove its own cwd.
 	mainWT, _ := FindMainWorktree()
 
+	// A leased worktree is in use by its owner, even with --force.
+	if err := checkLease(path); err != nil {
+		return err
+	}
+
+	// `docker compose down -v` destroys volumes, so only stop the stack once
+	// git is known to accept the removal.
+	if err := checkRemovable(path, force); err != nil {
+		return err
+	}
+	stopCompose(path)
+	// The session is recorded in the worktree's git dir, which the removal
+	// deletes. It is ended only afterwards: gwt may be running inside it.
+	sess := Session(path)
+
 	args := []string{"worktree", "remove"}
 	if force {
 		args = append(args, "--force")
@@ -164,6 +319,30 @@ func Remove(path string, force bool) error {
 		}
 		return err
 	}
+	releaseRegistryEntry(root, path)
+	killSession(sess)
+	return nil
+}
+
+// checkRemovable returns the error `git worktree remove` would fail with for
+// the worktree at path: it is locked or, without force, has modified or
+// untracked files. Anything else is left for git to report.
+func checkRemovable(path string, force bool) error {
+	if gitDir, err := ReadGitFile(path); err == nil {
+		if data, err := os.ReadFile(filepath.Join(gitDir, "locked")); err == nil {
+			if reason := strings.TrimSpace(string(data)); reason != "" {
+				return fmt.Errorf("worktree %s is locked: %s", path, reason)
+			}
+			return fmt.Errorf("worktree %s is locked", path)
+		}
+	}
+	if force {
+		return nil
+	}
+	out, err := exec.Command("git", "-C", path, "status", "--porcelain", "--ignore-submodules=none").Output()
+	if err == nil && len(strings.TrimSpace(string(out))) > 0 {
+		return fmt.Errorf("'%s' contains modified or untracked files, use --force to delete it", path)
+	}
 	return nil
 }
 
@@ -309,3 +488,56 @@ func RunSetupCommandsOpts(workdir string, commands []string, verbose bool, timed
 	}
 	return nil
 }
+
+// CreateBranchAt creates refs/heads/<branch> pointing at sha without checking it out.
+func CreateBranchAt(commonGitDir, branch, sha string) error {
+	cmd := exec.Command("git", "--git-dir", commonGitDir, "branch", branch, sha)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git branch %s %s failed: %w: %s", branch, sha, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// MoveBranch points refs/heads/<branch> at newSHA, but only if it currently
+// points at oldSHA. It must not be used for branches checked out in a worktree.
+func MoveBranch(commonGitDir, branch, newSHA, oldSHA string) error {
+	cmd := exec.Command("git", "--git-dir", commonGitDir, "update-ref", "refs/heads/"+branch, newSHA, oldSHA)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git update-ref %s failed: %w: %s", branch, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// WorktreeStatus summarizes the working tree and upstream state of a worktree.
+type WorktreeStatus struct {
+	// Changes is the number of modified, staged, untracked or conflicted paths.
+	Changes  int    `json:"changes"`
+	Upstream string `json:"upstream,omitempty"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+}
+
+// Status reads the working tree status of the worktree at path.
+func Status(path string) (WorktreeStatus, error) {
+	cmd := exec.Command("git", "-C", path, "status", "--porcelain=v2", "--branch")
+	out, err := cmd.Output()
+	if err != nil {
+		return WorktreeStatus{}, fmt.Errorf("git status failed in %s: %w", path, err)
+	}
+	var st WorktreeStatus
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.upstream "):
+			st.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &st.Ahead, &st.Behind)
+		case strings.HasPrefix(line, "#"):
+		default:
+			st.Changes++
+		}
+	}
+	return st, nil
+}
@@ -0,0 +1,154 @@
+package worktree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePorcelain(t *testing.T) {
+	// Fields of `git worktree list --porcelain`, one slice per entry.
+	entries := [][]string{
+		{"worktree /repo.git", "bare"},
+		{"worktree /wt/main", "HEAD 1111111111111111111111111111111111111111", "branch refs/heads/main"},
+		{"worktree /wt/feature/x", "HEAD 2222222222222222222222222222222222222222", "branch refs/heads/feature/x", "locked"},
+		{"worktree /wt/detached", "HEAD 3333333333333333333333333333333333333333", "detached", "locked gwt lease: agent-1"},
+		{"worktree /wt/gone", "HEAD 4444444444444444444444444444444444444444", "branch refs/heads/gone", "prunable gitdir file points to non-existent location"},
+	}
+	want := []Worktree{
+		{Path: "/repo.git", Bare: true},
+		{Path: "/wt/main", Head: "1111111111111111111111111111111111111111", Branch: "main"},
+		{Path: "/wt/feature/x", Head: "2222222222222222222222222222222222222222", Branch: "feature/x", Locked: true},
+		{Path: "/wt/detached", Head: "3333333333333333333333333333333333333333", Detached: true, Locked: true, LockReason: "gwt lease: agent-1"},
+		{Path: "/wt/gone", Head: "4444444444444444444444444444444444444444", Branch: "gone", Prunable: true, PrunableReason: "gitdir file points to non-existent location"},
+	}
+
+	for _, sep := range []string{"\n", "\x00"} {
+		var b strings.Builder
+		for _, fields := range entries {
+			for _, f := range fields {
+				b.WriteString(f + sep)
+			}
+			b.WriteString(sep)
+		}
+		if got := parsePorcelain(b.String(), sep); !reflect.DeepEqual(got, want) {
+			t.Errorf("sep %q:\n got %+v\nwant %+v", sep, got, want)
+		}
+	}
+}
+
+func TestParsePorcelainEdgeCases(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		sep    string
+		want   []Worktree
+	}{
+		{name: "empty", output: "", sep: "\n"},
+		{
+			name:   "no trailing blank line",
+			output: "worktree /a\nHEAD 1\nbranch refs/heads/a",
+			sep:    "\n",
+			want:   []Worktree{{Path: "/a", Head: "1", Branch: "a"}},
+		},
+		{
+			name:   "newline in a path with -z",
+			output: "worktree /wt/odd\nname\x00HEAD 1\x00detached\x00\x00worktree /wt/b\x00HEAD 2\x00branch refs/heads/b\x00\x00",
+			sep:    "\x00",
+			want:   []Worktree{{Path: "/wt/odd\nname", Head: "1", Detached: true}, {Path: "/wt/b", Head: "2", Branch: "b"}},
+		},
+		{
+			name:   "space in a path and lock reason",
+			output: "worktree /wt/with space\nHEAD 1\nbranch refs/heads/s\nlocked on a usb drive\n\n",
+			sep:    "\n",
+			want:   []Worktree{{Path: "/wt/with space", Head: "1", Branch: "s", Locked: true, LockReason: "on a usb drive"}},
+		},
+		{
+			name:   "unknown fields are ignored",
+			output: "worktree /a\nHEAD 1\nbranch refs/heads/a\nfuture thing\n\n",
+			sep:    "\n",
+			want:   []Worktree{{Path: "/a", Head: "1", Branch: "a"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := parsePorcelain(tt.output, tt.sep); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListAllPathWithNewline(t *testing.T) {
+	clone, _ := testClone(t)
+	chdir(t, clone)
+	path := filepath.Join(t.TempDir(), "odd\nname")
+	git(t, clone, "worktree", "add", "-q", "--detach", path)
+
+	worktrees, err := ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(worktrees) != 2 || !SamePath(worktrees[1].Path, path) || !worktrees[1].Detached {
+		t.Errorf("ListAll() = %+v, want the main worktree and a detached one at %q", worktrees, path)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"main", "feature/x", "a/scratch", "b/scratch", "review"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	worktrees := []Worktree{
+		{Path: filepath.Join(dir, "main"), Branch: "main"},
+		{Path: filepath.Join(dir, "feature/x"), Branch: "feature/x"},
+		{Path: filepath.Join(dir, "a/scratch"), Detached: true},
+		{Path: filepath.Join(dir, "b/scratch"), Detached: true},
+		{Path: filepath.Join(dir, "review"), Detached: true},
+	}
+
+	tests := []struct {
+		name     string
+		arg      string
+		wantPath string
+		wantErr  string
+	}{
+		{name: "branch", arg: "feature/x", wantPath: "feature/x"},
+		{name: "path of a branch worktree", arg: filepath.Join(dir, "main"), wantPath: "main"},
+		{name: "unique detached name", arg: "review", wantPath: "review"},
+		{name: "path of a detached worktree", arg: filepath.Join(dir, "b/scratch"), wantPath: "b/scratch"},
+		{name: "uncleaned path", arg: filepath.Join(dir, "a", "..", "a", "scratch") + "/", wantPath: "a/scratch"},
+		{name: "ambiguous detached name", arg: "scratch", wantErr: "'scratch' names several detached worktrees"},
+		{name: "directory name of a branch worktree", arg: "x", wantErr: "worktree for branch 'x' not found"},
+		{name: "unknown", arg: "nope", wantErr: "worktree for branch 'nope' not found"},
+	}
+	for _, tt := range tests {
+		wt, err := Find(worktrees, tt.arg)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Find(%q) error = %v, want %q", tt.name, tt.arg, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Find(%q): %v", tt.name, tt.arg, err)
+			continue
+		}
+		if want := filepath.Join(dir, tt.wantPath); wt.Path != want {
+			t.Errorf("%s: Find(%q) = %s, want %s", tt.name, tt.arg, wt.Path, want)
+		}
+	}
+
+	// A path relative to the working directory works too.
+	chdir(t, dir)
+	if wt, err := Find(worktrees, "a/scratch"); err != nil || wt.Path != filepath.Join(dir, "a/scratch") {
+		t.Errorf("Find(%q) = %+v, %v", "a/scratch", wt, err)
+	}
+	var notFound *NotFoundError
+	if _, err := Find(worktrees, "nope"); !errors.As(err, &notFound) || notFound.Name != "nope" {
+		t.Errorf("Find(nope) error = %v, want a *NotFoundError", err)
+	}
+}